@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"main/database"
+	"main/handler"
+	"os"
+)
+
+// errUsage подсказка по подкомандам
+var errUsage = errors.New("usage: main priorities check|compact|rebalance [-project N]")
+
+// runCommand выполняем подкоманду вместо запуска сервера
+func runCommand(rh handler.RestHandler, args []string) error {
+	switch args[0] {
+	case "priorities":
+		return prioritiesCommand(rh, args[1:])
+	default:
+		return errUsage
+	}
+}
+
+// prioritiesCommand проверяем приоритеты, плотно перенумеровываем их или перебалансируем ранги.
+// check завершается с ошибкой, если нашлись проблемы, чтобы его можно было запускать по расписанию
+func prioritiesCommand(rh handler.RestHandler, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	fs := flag.NewFlagSet("priorities "+args[0], flag.ContinueOnError)
+	pID := fs.Int("project", 0, "id проекта, 0 - все проекты")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	var payload []byte
+	var err error
+	switch args[0] {
+	case "check":
+		payload, err = database.CheckPriorities(rh.DataBase, *pID)
+	case "compact":
+		payload, err = rh.CompactPriorities(*pID)
+	case "rebalance":
+		// позиции не меняются, печатаем отчет проверки после перебалансировки
+		err = database.RebalanceRanks(rh.DataBase, *pID)
+		if err == nil {
+			payload, err = database.CheckPriorities(rh.DataBase, *pID)
+		}
+	default:
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(payload))
+
+	if args[0] != "check" {
+		return nil
+	}
+	res := database.PrioritiesResponse{}
+	if err = json.Unmarshal(payload, &res); err != nil {
+		return err
+	}
+	for _, report := range res.Projects {
+		if report.HasIssues() {
+			return fmt.Errorf("priority issues found in project %d", report.ProjectID)
+		}
+	}
+	return nil
+}
+
+// exitOnCommand запускаем подкоманду, если она передана, и завершаемся
+func exitOnCommand(rh handler.RestHandler) {
+	if len(os.Args) < 2 {
+		return
+	}
+	if err := runCommand(rh, os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"main/database"
+	"main/grpcapi"
+	"main/handler"
 	natsLog "main/nats"
 	"os"
 
@@ -10,9 +12,15 @@ import (
 
 // Config структура для конфига
 type Config struct {
-	Postgres database.PostgresConfig `yaml:"postgres"`
-	Redis    database.RedisConfig    `yaml:"redis"`
-	Nats     natsLog.NatsConfig      `yaml:"nats"`
+	Postgres    database.PostgresConfig   `yaml:"postgres"`
+	Redis       database.RedisConfig      `yaml:"redis"`
+	Nats        natsLog.NatsConfig        `yaml:"nats"`
+	Retention   database.RetentionConfig  `yaml:"retention"`
+	Idempotency handler.IdempotencyConfig `yaml:"idempotency"`
+	Grpc        grpcapi.GrpcConfig        `yaml:"grpc"`
+	GraphQL     handler.GraphQLConfig     `yaml:"graphql"`
+	Webhooks    handler.WebhooksConfig    `yaml:"webhooks"`
+	Batch       handler.BatchConfig       `yaml:"batch"`
 }
 
 // loadConfig читаем конфиг
@@ -0,0 +1,263 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	natsLog "main/nats"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// NewGood данные для создания товара. Priority и Position необязательны,
+// без них товар встает в конец проекта
+type NewGood struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+	Priority    int     `json:"priority"`
+	Position    string  `json:"position"`
+}
+
+// BatchItem результат по одному элементу пачки
+type BatchItem struct {
+	Index int   `json:"index"`
+	Good  *Good `json:"good"`
+}
+
+// BatchResponse структура ответа для пачки
+type BatchResponse struct {
+	Items []BatchItem `json:"items"`
+}
+
+// ErrBadBatch сообщение если в пачке есть неверные элементы
+var ErrBadBatch = errors.New("batch has invalid items")
+
+// ItemError ошибка проверки элемента пачки с номером Index
+type ItemError struct {
+	Index int
+	Err   error
+}
+
+// BatchError неверные элементы пачки, errors.Is(err, ErrBadBatch) для нее истинно
+type BatchError struct {
+	Items []ItemError
+}
+
+// Error текст ошибки
+func (e *BatchError) Error() string {
+	return ErrBadBatch.Error()
+}
+
+// Is ошибка пачки - это ErrBadBatch
+func (e *BatchError) Is(target error) bool {
+	return target == ErrBadBatch
+}
+
+// ошибки проверки данных для создания товара
+var (
+	ErrNameRequired       = errors.New("name not provided")
+	ErrNegativePriority   = errors.New("priority must be positive")
+	ErrBadPosition        = errors.New("position must be top or bottom")
+	ErrPriorityOrPosition = errors.New("only one of priority or position may be provided")
+)
+
+// Validate проверяем данные для создания товара
+func (g NewGood) Validate() error {
+	if g.Name == "" {
+		return ErrNameRequired
+	}
+	if g.Priority < 0 {
+		return ErrNegativePriority
+	}
+	if g.Position != "" && g.Position != PositionTop && g.Position != PositionBottom {
+		return ErrBadPosition
+	}
+	if g.Priority != 0 && g.Position != "" {
+		return ErrPriorityOrPosition
+	}
+	return nil
+}
+
+// insertGood добавляем товар в заблокированный проект и ставим его на нужную позицию
+func insertGood(tx *sql.Tx, pID int, g NewGood) (ID int, err error) {
+	// проект заблокирован, поэтому следующий ранг в нем выдается без гонок
+	err = tx.QueryRow("insert into test_issue.goods (project_id, name, description, rank) values($1, $2, $3, next_rank($1)) returning id", pID, g.Name, g.Description).Scan(&ID)
+	if err != nil {
+		return 0, err
+	}
+	if g.Priority == 0 && g.Position != PositionTop {
+		return ID, nil
+	}
+	move := Move{Priority: g.Priority, Position: g.Position}
+	err = placeGood(tx, ID, pID, func() (prev, next *int64, err error) {
+		return move.neighbours(tx, ID, pID)
+	})
+	return ID, err
+}
+
+// InsertGoods добавляем пачку товаров в проект одной транзакцией. Если хоть один элемент
+// неверный, ничего не добавляется, а ошибки по каждому неверному элементу возвращаются в *BatchError
+func InsertGoods(db *sql.DB, pID int, goods []NewGood) (payload json.RawMessage, logPayload []natsLog.LogMessage, err error) {
+	if len(goods) == 0 {
+		return nil, nil, ErrBadBatch
+	}
+	batchErr := &BatchError{}
+	for i, g := range goods {
+		if err := g.Validate(); err != nil {
+			batchErr.Items = append(batchErr.Items, ItemError{Index: i, Err: err})
+		}
+	}
+	if len(batchErr.Items) > 0 {
+		return nil, nil, batchErr
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	_, err = lockProject(tx, pID)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	IDs := make([]int, len(goods))
+	for i, g := range goods {
+		IDs[i], err = insertGood(tx, pID, g)
+		if err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
+	}
+	// позиции читаем одним запросом после всех вставок, они могли сдвинуться
+	inserted, err := findGoods(tx, pID, IDs)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	items := make([]BatchItem, len(IDs))
+	now := time.Now()
+	for i, ID := range IDs {
+		good, ok := inserted[ID]
+		if !ok {
+			tx.Rollback()
+			return nil, nil, ErrNotFound
+		}
+		items[i] = BatchItem{Index: i, Good: &good}
+		logPayload = append(logPayload, natsLog.LogMessage{
+			ID:          good.ID,
+			ProjectID:   pID,
+			Name:        good.Name,
+			Description: good.Description,
+			Priority:    good.Priority,
+			Removed:     good.Removed,
+			Version:     good.Version,
+			Event:       natsLog.EventCreate,
+			EventTime:   now,
+		})
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payload, err = json.Marshal(BatchResponse{Items: items})
+	if err != nil {
+		return nil, nil, err
+	}
+	return payload, logPayload, nil
+}
+
+// findGoods читаем товары проекта по id вместе с их позициями
+func findGoods(q querier, pID int, IDs []int) (map[int]Good, error) {
+	rows, err := q.Query("select "+goodColumns+" from test_issue.goods g where g.project_id = $1 and g.id = any($2)", pID, pq.Array(IDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	goods := make(map[int]Good, len(IDs))
+	for rows.Next() {
+		good, err := scanGood(rows)
+		if err != nil {
+			return nil, err
+		}
+		goods[good.ID] = good
+	}
+	return goods, rows.Err()
+}
+
+// RemovedResponse структура ответа для пачечного удаления и восстановления
+type RemovedResponse struct {
+	Goods    []Good `json:"goods"`
+	NotFound []int  `json:"notFound"`
+	// Unchanged товары, которые уже были удалены или не были удалены
+	Unchanged []int `json:"unchanged"`
+}
+
+// SetGoodsRemoved помечаем пачку товаров проекта удаленными или восстанавливаем их.
+// Меняются только товары в другом состоянии: у уже удаленных не сбрасывается removed_at,
+// не растет версия и не уходит событие. Ненайденные и неизмененные id не мешают остальным и возвращаются в ответе
+func SetGoodsRemoved(db *sql.DB, pID int, IDs []int, removed bool) (payload json.RawMessage, logPayload []natsLog.LogMessage, err error) {
+	if len(IDs) == 0 {
+		return nil, nil, ErrBadBatch
+	}
+	rows, err := db.Query(`with target as (select id, removed from test_issue.goods where project_id = $1 and id = any($2)),
+		changed as (update test_issue.goods g set removed = $3, removed_at = `+removedAt+`, version = version + 1
+			from target t where g.project_id = $1 and g.id = t.id and t.removed is distinct from $3
+			returning g.id, g.version)
+		select t.id, coalesce(c.version, 0) from target t left join changed c on c.id = t.id`, pID, pq.Array(IDs), removed)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	// новая версия найденного товара, 0 - товар уже был в нужном состоянии
+	found := map[int]int{}
+	for rows.Next() {
+		ID, version := 0, 0
+		if err = rows.Scan(&ID, &version); err != nil {
+			return nil, nil, err
+		}
+		found[ID] = version
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	res := RemovedResponse{Goods: []Good{}, NotFound: []int{}, Unchanged: []int{}}
+	now := time.Now()
+	// один id мог прийти несколько раз
+	seen := map[int]bool{}
+	for _, ID := range IDs {
+		if seen[ID] {
+			continue
+		}
+		seen[ID] = true
+		version, ok := found[ID]
+		if !ok {
+			res.NotFound = append(res.NotFound, ID)
+			continue
+		}
+		if version == 0 {
+			res.Unchanged = append(res.Unchanged, ID)
+			continue
+		}
+		res.Goods = append(res.Goods, Good{ID: ID, ProjectID: pID, Removed: removed, Version: version})
+		logPayload = append(logPayload, natsLog.LogMessage{
+			ID:        ID,
+			ProjectID: pID,
+			Removed:   removed,
+			Version:   version,
+			Event:     removedEvent(removed),
+			EventTime: now,
+		})
+	}
+
+	payload, err = json.Marshal(res)
+	if err != nil {
+		return nil, nil, err
+	}
+	return payload, logPayload, nil
+}
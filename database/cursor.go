@@ -0,0 +1,105 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Page параметры постраничной выдачи
+type Page struct {
+	Limit  int
+	Offset int
+	// Keyset выдача по курсору вместо offset
+	Keyset bool
+	Cursor string
+}
+
+// cursor содержимое курсора: сортировка, значение ключа сортировки и id последнего товара.
+// Для priority ключ - проект и ранг, а не позиция: позиции сдвигаются при перемещении соседей
+type cursor struct {
+	Sort    string `json:"s"`
+	Project int    `json:"p,omitempty"`
+	Key     string `json:"k,omitempty"`
+	ID      int    `json:"id"`
+}
+
+// ErrBadCursor сообщение если курсор не разобрать или он от другой сортировки
+var ErrBadCursor = errors.New("bad cursor")
+
+// ErrLimitNotPositive сообщение если limit страницы не положительный
+var ErrLimitNotPositive = errors.New("limit must be positive")
+
+// encodeCursor кодируем курсор после товара good
+func encodeCursor(sort string, good Good) (string, error) {
+	c := cursor{Sort: sort, ID: good.ID}
+	switch strings.TrimPrefix(sort, "-") {
+	case "priority":
+		c.Project = good.ProjectID
+		c.Key = strconv.FormatInt(good.rank, 10)
+	case "createdAt":
+		if good.CreatedAt != nil {
+			c.Key = good.CreatedAt.Format(time.RFC3339Nano)
+		}
+	}
+	out, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(out), nil
+}
+
+// decodeCursor разбираем курсор и получаем аргументы для колонок сортировки из sortColumns
+func decodeCursor(s, sort string) (c cursor, keys []any, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, nil, ErrBadCursor
+	}
+	if err = json.Unmarshal(raw, &c); err != nil {
+		return c, nil, ErrBadCursor
+	}
+	if c.Sort != sort {
+		return c, nil, fmt.Errorf("%w: cursor sort %q does not match %q", ErrBadCursor, c.Sort, sort)
+	}
+	switch strings.TrimPrefix(sort, "-") {
+	case "priority":
+		var rank int64
+		rank, err = strconv.ParseInt(c.Key, 10, 64)
+		keys = []any{c.Project, rank}
+	case "createdAt":
+		var createdAt time.Time
+		createdAt, err = time.Parse(time.RFC3339Nano, c.Key)
+		keys = []any{createdAt}
+	}
+	if err != nil {
+		return c, nil, ErrBadCursor
+	}
+	return c, keys, nil
+}
+
+// afterCursor условие выборки товаров после курсора, n - число уже занятых плейсхолдеров
+func (f GoodsFilter) afterCursor(s string, n int) (cond string, args []any, err error) {
+	sort := f.sortOrDefault()
+	c, keys, err := decodeCursor(s, sort)
+	if err != nil {
+		return "", nil, err
+	}
+	op := ">"
+	if strings.HasPrefix(sort, "-") {
+		op = "<"
+	}
+	columns := append(append([]string{}, sortColumns[strings.TrimPrefix(sort, "-")]...), "id")
+	args = append(keys, c.ID)
+	placeholders := make([]string, len(args))
+	for i := range args {
+		placeholders[i] = fmt.Sprintf("$%d", n+i+1)
+	}
+	if len(columns) == 1 {
+		return fmt.Sprintf("id %s %s", op, placeholders[0]), args, nil
+	}
+	return fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), op, strings.Join(placeholders, ", ")), args, nil
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GoodsFilter параметры фильтрации и сортировки списка товаров
+type GoodsFilter struct {
+	ProjectID   int
+	Removed     *bool
+	Search      string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Sort        string
+}
+
+// sortColumns допустимые поля сортировки и колонки test_issue.goods, по которым сортируем до id.
+// Приоритет - позиция по рангу внутри проекта, поэтому без фильтра по проекту товары идут по проектам
+var sortColumns = map[string][]string{
+	"id":        nil,
+	"priority":  {"project_id", "rank"},
+	"createdAt": {"created_at"},
+}
+
+// ErrBadSort сообщение если передана неизвестная сортировка
+var ErrBadSort = errors.New("unknown sort field")
+
+// Validate проверяем параметры фильтра
+func (f GoodsFilter) Validate() error {
+	if f.Sort == "" {
+		return nil
+	}
+	if _, ok := sortColumns[strings.TrimPrefix(f.Sort, "-")]; !ok {
+		return fmt.Errorf("%w: %s", ErrBadSort, f.Sort)
+	}
+	return nil
+}
+
+// CacheKey нормализованное представление фильтра для ключа кеша
+func (f GoodsFilter) CacheKey() string {
+	parts := []string{"p=" + strconv.Itoa(f.ProjectID)}
+	if f.Removed != nil {
+		parts = append(parts, "r="+strconv.FormatBool(*f.Removed))
+	}
+	if f.Search != "" {
+		parts = append(parts, "q="+strings.ToLower(f.Search))
+	}
+	if f.CreatedFrom != nil {
+		parts = append(parts, "cf="+f.CreatedFrom.UTC().Format(time.RFC3339Nano))
+	}
+	if f.CreatedTo != nil {
+		parts = append(parts, "ct="+f.CreatedTo.UTC().Format(time.RFC3339Nano))
+	}
+	parts = append(parts, "s="+f.sortOrDefault())
+	return strings.Join(parts, "&")
+}
+
+// sortOrDefault сортировка по умолчанию - по id
+func (f GoodsFilter) sortOrDefault() string {
+	if f.Sort == "" {
+		return "id"
+	}
+	return f.Sort
+}
+
+// orderBy выражение сортировки, id добавляется для стабильного порядка
+func (f GoodsFilter) orderBy() string {
+	sort := f.sortOrDefault()
+	dir := ""
+	if strings.HasPrefix(sort, "-") {
+		dir = " desc"
+	}
+	parts := []string{}
+	for _, column := range sortColumns[strings.TrimPrefix(sort, "-")] {
+		parts = append(parts, column+dir)
+	}
+	return strings.Join(append(parts, "id"+dir), ", ")
+}
+
+// conditions условия выборки и их аргументы, нумерация плейсхолдеров начинается с 1
+func (f GoodsFilter) conditions() (conds []string, args []any) {
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if f.ProjectID != 0 {
+		add("project_id = $%d", f.ProjectID)
+	}
+	if f.Removed != nil {
+		add("removed = $%d", *f.Removed)
+	}
+	if f.Search != "" {
+		add("(name ilike $%[1]d or description ilike $%[1]d)", "%"+escapeLike(f.Search)+"%")
+	}
+	if f.CreatedFrom != nil {
+		add("created_at >= $%d", *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		add("created_at <= $%d", *f.CreatedTo)
+	}
+	return conds, args
+}
+
+// whereClause собираем условия в where
+func whereClause(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return " where " + strings.Join(conds, " and ")
+}
+
+// escapeLike экранируем спецсимволы шаблона like
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	natsLog "main/nats"
+	"time"
+)
+
+// HistoryEntry запись истории товара: событие и состояние товара после него.
+// Поля, которых нет в событии, пустые
+type HistoryEntry struct {
+	ID          int64     `json:"id"`
+	GoodID      int       `json:"goodId"`
+	ProjectID   int       `json:"projectId"`
+	Event       string    `json:"event"`
+	Name        *string   `json:"name,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Priority    *int      `json:"priority,omitempty"`
+	Removed     bool      `json:"removed"`
+	Version     *int      `json:"version,omitempty"`
+	EventTime   time.Time `json:"eventTime"`
+}
+
+// SaveHistory записываем события в историю одним запросом
+func SaveHistory(db execer, msgs []natsLog.LogMessage) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	out, err := json.Marshal(msgs)
+	if err != nil {
+		return err
+	}
+	// пустые name, priority и version в событии не передаются, в истории это null
+	_, err = db.Exec(`insert into test_issue.goods_history (good_id, project_id, event, name, description, priority, removed, version, event_time)
+		select m.id, m.project_id, m.event, nullif(m.name, ''), m.description, nullif(m.priority, 0), coalesce(m.removed, false) or coalesce(m.purged, false), nullif(m.version, 0), m.event_time
+		from jsonb_to_recordset($1::jsonb) as m(id integer, project_id integer, event text, name text, description text,
+			priority integer, removed boolean, purged boolean, version integer, event_time timestamptz)`, string(out))
+	return err
+}
+
+// FindHistory последние limit записей истории товара ID проекта pID, новые сначала. ID 0 - история всего проекта
+func FindHistory(db *sql.DB, ID, pID, limit int) (entries []HistoryEntry, err error) {
+	rows, err := db.Query(`select id, good_id, project_id, event, name, description, priority, removed, version, event_time
+		from test_issue.goods_history
+		where project_id = $1 and ($2 = 0 or good_id = $2)
+		order by id desc limit $3`, pID, ID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries = []HistoryEntry{}
+	for rows.Next() {
+		entry := HistoryEntry{}
+		err = rows.Scan(&entry.ID, &entry.GoodID, &entry.ProjectID, &entry.Event, &entry.Name, &entry.Description,
+			&entry.Priority, &entry.Removed, &entry.Version, &entry.EventTime)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"encoding/json"
+	natsLog "main/nats"
+	"testing"
+	"time"
+)
+
+// TestSaveHistory пачка событий пишется одним запросом, пустая пачка не пишется
+func TestSaveHistory(t *testing.T) {
+	now := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC)
+	msgs := []natsLog.LogMessage{
+		{ID: 1, ProjectID: 2, Name: "a", Priority: 1, Version: 1, Event: natsLog.EventCreate, EventTime: now},
+		{ID: 1, ProjectID: 2, Removed: true, Version: 2, Event: natsLog.EventRemove, EventTime: now},
+		{ID: 3, ProjectID: 2, Purged: true, Event: natsLog.EventPurge, EventTime: now},
+	}
+	tx := &fakeExec{}
+	if err := SaveHistory(tx, msgs); err != nil {
+		t.Fatalf("SaveHistory: %v", err)
+	}
+	if len(tx.queries) != 1 {
+		t.Fatalf("queries %q, want one insert", tx.queries)
+	}
+	saved := []natsLog.LogMessage{}
+	if err := json.Unmarshal([]byte(tx.args[0][0].(string)), &saved); err != nil {
+		t.Fatalf("arg %v: %v", tx.args[0][0], err)
+	}
+	if len(saved) != len(msgs) {
+		t.Fatalf("saved %d events, want %d", len(saved), len(msgs))
+	}
+	for i := range msgs {
+		if saved[i].ID != msgs[i].ID || saved[i].Event != msgs[i].Event || !saved[i].EventTime.Equal(msgs[i].EventTime) {
+			t.Errorf("event %d = %+v, want %+v", i, saved[i], msgs[i])
+		}
+	}
+
+	tx = &fakeExec{}
+	if err := SaveHistory(tx, nil); err != nil || len(tx.queries) != 0 {
+		t.Errorf("no events: err %v, queries %q, want nothing to insert", err, tx.queries)
+	}
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// StoredResponse ответ, сохраненный под ключом идемпотентности.
+// Пока запрос выполняется, Done = false и сохранен только отпечаток запроса
+type StoredResponse struct {
+	Fingerprint string      `json:"fingerprint"`
+	Done        bool        `json:"done"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// idempotencyKey ключ в redis для ключа идемпотентности
+func idempotencyKey(key string) string {
+	return "idempotency:" + key
+}
+
+// ReserveIdempotencyKey занимаем ключ под запрос с отпечатком fingerprint на время lockTTL,
+// срок хранения ответа задается при сохранении. Если ключ уже занят, возвращаем то, что под ним сохранено
+func ReserveIdempotencyKey(db *redis.Client, key, fingerprint string, lockTTL time.Duration) (stored StoredResponse, reserved bool, err error) {
+	ctx := context.Background()
+	out, err := json.Marshal(StoredResponse{Fingerprint: fingerprint})
+	if err != nil {
+		return stored, false, err
+	}
+	reserved, err = db.SetNX(ctx, idempotencyKey(key), out, lockTTL).Result()
+	if err != nil || reserved {
+		return stored, reserved, err
+	}
+
+	res, err := db.Get(ctx, idempotencyKey(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// ключ успел истечь, пробуем занять его заново
+		return ReserveIdempotencyKey(db, key, fingerprint, lockTTL)
+	}
+	if err != nil {
+		return stored, false, err
+	}
+	err = json.Unmarshal(res, &stored)
+	return stored, false, err
+}
+
+// SaveIdempotentResponse сохраняем ответ под занятым ключом на ttl
+func SaveIdempotentResponse(db *redis.Client, key string, stored StoredResponse, ttl time.Duration) error {
+	ctx := context.Background()
+	stored.Done = true
+	out, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	return db.Set(ctx, idempotencyKey(key), out, ttl).Err()
+}
+
+// ReleaseIdempotencyKey освобождаем ключ, чтобы запрос можно было повторить
+func ReleaseIdempotencyKey(db *redis.Client, key string) error {
+	ctx := context.Background()
+	return db.Del(ctx, idempotencyKey(key)).Err()
+}
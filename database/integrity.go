@@ -0,0 +1,225 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	natsLog "main/nats"
+	"time"
+)
+
+// PriorityReport результат проверки приоритетов проекта
+type PriorityReport struct {
+	ProjectID int `json:"projectId"`
+	Goods     int `json:"goods"`
+	// DuplicateRanks группы товаров с одинаковым рангом, их порядок держится только на id
+	DuplicateRanks [][]int `json:"duplicateRanks,omitempty"`
+	// Crowded сколько соседних пар не оставляют места для вставки между ними
+	Crowded int `json:"crowded"`
+	// RemovedSlots удаленные товары, которые занимают позиции перед неудаленными
+	RemovedSlots []int `json:"removedSlots,omitempty"`
+	// Renumbered товары, у которых после перенумерации поменялась позиция
+	Renumbered []Good `json:"renumbered,omitempty"`
+}
+
+// PrioritiesResponse структура ответа проверки приоритетов
+type PrioritiesResponse struct {
+	Projects []PriorityReport `json:"projects"`
+}
+
+// HasIssues есть ли в проекте что исправлять
+func (r PriorityReport) HasIssues() bool {
+	return len(r.DuplicateRanks) > 0 || r.Crowded > 0 || len(r.RemovedSlots) > 0
+}
+
+// rankedGood товар с рангом в порядке проекта
+type rankedGood struct {
+	ID      int
+	Rank    int64
+	Removed bool
+}
+
+// CheckPriorities проверяем приоритеты проекта, при pID = 0 всех проектов
+func CheckPriorities(db *sql.DB, pID int) (payload json.RawMessage, err error) {
+	projectIDs, err := priorityProjects(db, pID)
+	if err != nil {
+		return nil, err
+	}
+	reports := []PriorityReport{}
+	for _, ID := range projectIDs {
+		goods, err := loadRanked(db, ID)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, checkRanked(ID, goods))
+	}
+	return json.Marshal(PrioritiesResponse{Projects: reports})
+}
+
+// CompactPriorities плотно перенумеровываем ранги проекта, при pID = 0 всех проектов.
+// Неудаленные товары идут первыми в прежнем порядке, удаленные за ними.
+// Каждый проект перенумеровывается в своей транзакции под блокировкой
+func CompactPriorities(db *sql.DB, pID int) (payload json.RawMessage, logPayload []natsLog.LogMessage, err error) {
+	projectIDs, err := priorityProjects(db, pID)
+	if err != nil {
+		return nil, nil, err
+	}
+	reports := []PriorityReport{}
+	for _, ID := range projectIDs {
+		report, msgs, err := compactProject(db, ID)
+		if errors.Is(err, ErrProjectNotFound) && pID == 0 {
+			// проект удалили, пока перенумеровывали остальные
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		reports = append(reports, report)
+		logPayload = append(logPayload, msgs...)
+	}
+	payload, err = json.Marshal(PrioritiesResponse{Projects: reports})
+	if err != nil {
+		return nil, nil, err
+	}
+	return payload, logPayload, nil
+}
+
+// compactProject перенумеровываем ранги одного проекта. Сами товары не меняются, поэтому версия у них прежняя
+func compactProject(db *sql.DB, pID int) (report PriorityReport, logPayload []natsLog.LogMessage, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return report, nil, err
+	}
+	_, err = lockProject(tx, pID)
+	if err != nil {
+		tx.Rollback()
+		return report, nil, err
+	}
+	goods, err := loadRanked(tx, pID)
+	if err != nil {
+		tx.Rollback()
+		return report, nil, err
+	}
+	report = checkRanked(pID, goods)
+
+	order := make([]int, 0, len(goods))
+	for _, g := range goods {
+		if !g.Removed {
+			order = append(order, g.ID)
+		}
+	}
+	for _, g := range goods {
+		if g.Removed {
+			order = append(order, g.ID)
+		}
+	}
+	positions := make(map[int]int, len(goods))
+	for i, g := range goods {
+		positions[g.ID] = i + 1
+	}
+	report.Renumbered, err = applyOrder(tx, pID, order, positions)
+	if err != nil {
+		tx.Rollback()
+		return report, nil, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return report, nil, err
+	}
+
+	now := time.Now()
+	for _, g := range report.Renumbered {
+		logPayload = append(logPayload, natsLog.LogMessage{
+			ID:        g.ID,
+			ProjectID: pID,
+			Priority:  g.Priority,
+			Version:   g.Version,
+			Event:     natsLog.EventReprioritize,
+			EventTime: now,
+		})
+	}
+	return report, logPayload, nil
+}
+
+// checkRanked ищем проблемы в упорядоченных по рангу товарах проекта
+func checkRanked(pID int, goods []rankedGood) PriorityReport {
+	report := PriorityReport{ProjectID: pID, Goods: len(goods)}
+	lastActive := -1
+	for i, g := range goods {
+		if !g.Removed {
+			lastActive = i
+		}
+	}
+	for i, g := range goods {
+		if g.Removed && i < lastActive {
+			report.RemovedSlots = append(report.RemovedSlots, g.ID)
+		}
+		if i == 0 {
+			continue
+		}
+		prev := goods[i-1]
+		if g.Rank-prev.Rank < 2 {
+			report.Crowded++
+		}
+		if g.Rank != prev.Rank {
+			continue
+		}
+		// товары с одинаковым рангом идут подряд, собираем их в одну группу
+		if i > 1 && goods[i-2].Rank == g.Rank {
+			last := report.DuplicateRanks[len(report.DuplicateRanks)-1]
+			report.DuplicateRanks[len(report.DuplicateRanks)-1] = append(last, g.ID)
+		} else {
+			report.DuplicateRanks = append(report.DuplicateRanks, []int{prev.ID, g.ID})
+		}
+	}
+	return report
+}
+
+// querier общий интерфейс для *sql.DB и *sql.Tx
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// loadRanked товары проекта в порядке ранга
+func loadRanked(q querier, pID int) ([]rankedGood, error) {
+	rows, err := q.Query("select id, rank, removed from test_issue.goods where project_id = $1 order by rank, id", pID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	goods := []rankedGood{}
+	for rows.Next() {
+		g := rankedGood{}
+		if err = rows.Scan(&g.ID, &g.Rank, &g.Removed); err != nil {
+			return nil, err
+		}
+		goods = append(goods, g)
+	}
+	return goods, rows.Err()
+}
+
+// priorityProjects проекты для проверки: один или все
+func priorityProjects(q querier, pID int) ([]int, error) {
+	rows, err := q.Query("select id from test_issue.projects where $1 = 0 or id = $1 order by id", pID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	IDs := []int{}
+	for rows.Next() {
+		ID := 0
+		if err = rows.Scan(&ID); err != nil {
+			return nil, err
+		}
+		IDs = append(IDs, ID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if pID != 0 && len(IDs) == 0 {
+		return nil, ErrProjectNotFound
+	}
+	return IDs, nil
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// OptionalString поле JSON Merge Patch: Set - поле есть в запросе, Null - передан явный null
+type OptionalString struct {
+	Set   bool
+	Null  bool
+	Value string
+}
+
+// UnmarshalJSON вызывается только для полей, которые есть в запросе, в том числе для null
+func (o *OptionalString) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
+
+// GoodPatch изменения товара по JSON Merge Patch (RFC 7396): отсутствующие поля не меняются,
+// null очищает поле, если оно может быть пустым
+type GoodPatch struct {
+	Name        OptionalString `json:"name"`
+	Description OptionalString `json:"description"`
+}
+
+// ErrBadPatch сообщение если патч нельзя применить
+var ErrBadPatch = errors.New("bad patch")
+
+// Validate проверяем патч
+func (p GoodPatch) Validate() error {
+	if p.Name.Set && (p.Name.Null || p.Name.Value == "") {
+		return fmt.Errorf("%w: name cannot be null or empty", ErrBadPatch)
+	}
+	return nil
+}
+
+// Empty в патче нет ни одного поля
+func (p GoodPatch) Empty() bool {
+	return !p.Name.Set && !p.Description.Set
+}
+
+// assignments выражения set для update и их аргументы, n - число уже занятых плейсхолдеров
+func (p GoodPatch) assignments(n int) (set string, args []any) {
+	sets := []string{}
+	add := func(column string, o OptionalString) {
+		if !o.Set {
+			return
+		}
+		var arg any
+		if !o.Null {
+			arg = o.Value
+		}
+		args = append(args, arg)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, n+len(args)))
+	}
+	add("name", p.Name)
+	add("description", p.Description)
+	return strings.Join(sets, ", "), args
+}
@@ -28,10 +28,12 @@ type GoodsResponse struct {
 
 // Meta метаданные для ответа
 type Meta struct {
-	Total   int `json:"total"`
-	Removed int `json:"removed"`
-	Limit   int `json:"limit"`
-	Offset  int `json:"offset"`
+	Total      int    `json:"total"`
+	Removed    int    `json:"removed"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	Cursor     string `json:"cursor,omitempty"`
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 // Good структура товара
@@ -43,6 +45,9 @@ type Good struct {
 	Priority    int        `json:"priority,omitempty"`
 	Removed     bool       `json:"removed,omitempty"`
 	CreatedAt   *time.Time `json:"createdAt,omitempty"`
+	Version     int        `json:"version,omitempty"`
+	// rank ранг товара, нужен курсору сортировки по приоритету и наружу не отдается
+	rank int64
 }
 
 // ReprioritiizeResponse структура ответа для изменения приоритета
@@ -56,56 +61,65 @@ func GetDatabase(cfg PostgresConfig) (*sql.DB, error) {
 	return sql.Open("postgres", postgresqlDbInfo)
 }
 
-// FindGoods ищем товары
-func FindGoods(db *sql.DB, limit, offset int) (payload json.RawMessage, err error) {
-	rows, err := db.Query("select * from test_issue.goods order by id limit $1 offset $2", limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	rowTotal, err := db.Query("select count(*) from test_issue.goods")
-	if err != nil {
-		return nil, err
+// FindGoods ищем товары по фильтру, постранично через offset или через курсор
+func FindGoods(db *sql.DB, filter GoodsFilter, page Page) (payload json.RawMessage, err error) {
+	conds, args := filter.conditions()
+	where, countArgs := whereClause(conds), args
+
+	limit, offset := page.Limit, page.Offset
+	if page.Keyset {
+		// берем на одну запись больше, чтобы понять есть ли следующая страница
+		limit, offset = page.Limit+1, 0
+		if page.Cursor != "" {
+			cond, cArgs, err := filter.afterCursor(page.Cursor, len(args))
+			if err != nil {
+				return nil, err
+			}
+			conds = append(conds, cond)
+			args = append(args, cArgs...)
+		}
 	}
-	rowRemoved, err := db.Query("select count(*) from test_issue.goods where removed = true")
+	// сначала выбираем страницу, позиции считаем только для ее товаров
+	n, orderBy := len(args), filter.orderBy()
+	rows, err := db.Query(fmt.Sprintf("select "+goodColumns+" from (select * from test_issue.goods%s order by %s limit $%d offset $%d) g order by %s", whereClause(conds), orderBy, n+1, n+2, orderBy), append(args, limit, offset)...)
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		rows.Close()
-		rowTotal.Close()
-		rowRemoved.Close()
-	}()
+	defer rows.Close()
 
 	goods := make([]Good, 0, limit)
 	for rows.Next() {
-		good := Good{}
-		err = rows.Scan(&good.ID, &good.ProjectID, &good.Name, &good.Description, &good.Priority, &good.Removed, &good.CreatedAt)
+		good, err := scanGood(rows)
 		if err != nil {
 			return nil, err
 		}
 		goods = append(goods, good)
 	}
 
-	total := 0
-	rowTotal.Next()
-	err = rowTotal.Scan(&total)
-	if err != nil {
-		return nil, err
+	nextCursor := ""
+	if page.Keyset && len(goods) > page.Limit {
+		goods = goods[:page.Limit]
+		nextCursor, err = encodeCursor(filter.sortOrDefault(), goods[len(goods)-1])
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	removed := 0
-	rowRemoved.Next()
-	err = rowRemoved.Scan(&removed)
+	// считаем по тому же фильтру, без учета курсора
+	total, removed := 0, 0
+	err = db.QueryRow("select count(*), count(*) filter (where removed) from test_issue.goods"+where, countArgs...).Scan(&total, &removed)
 	if err != nil {
 		return nil, err
 	}
 
 	goodsResponse := GoodsResponse{
 		Meta: Meta{
-			Total:   total,
-			Removed: removed,
-			Limit:   limit,
-			Offset:  offset,
+			Total:      total,
+			Removed:    removed,
+			Limit:      page.Limit,
+			Offset:     page.Offset,
+			Cursor:     page.Cursor,
+			NextCursor: nextCursor,
 		},
 		Goods: goods,
 	}
@@ -117,11 +131,44 @@ func FindGoods(db *sql.DB, limit, offset int) (payload json.RawMessage, err erro
 	return out, nil
 }
 
-// InsertGood добавляем товар
-func InsertGood(db *sql.DB, pID int, name string) (payload, logPayload json.RawMessage, err error) {
-	row := db.QueryRow("insert into test_issue.goods (project_id, name) values($1, $2) returning *", pID, name)
-	good := Good{}
-	err = row.Scan(&good.ID, &good.ProjectID, &good.Name, &good.Description, &good.Priority, &good.Removed, &good.CreatedAt)
+// FindGood ищем товар по id и projectId
+func FindGood(db *sql.DB, ID, pID int) (payload json.RawMessage, err error) {
+	good, err := findGood(db, ID, pID)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := json.Marshal(good)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// InsertGood добавляем товар в конец проекта, на позицию priority или в начало/конец по position.
+// Остальные товары проекта сдвигаются так же, как при смене приоритета, все в одной транзакции
+func InsertGood(db *sql.DB, pID int, newGood NewGood) (payload, logPayload json.RawMessage, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	_, err = lockProject(tx, pID)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	ID, err := insertGood(tx, pID, newGood)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	good, err := findGood(tx, ID, pID)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	err = tx.Commit()
 	if err != nil {
 		return nil, nil, err
 	}
@@ -137,6 +184,8 @@ func InsertGood(db *sql.DB, pID int, name string) (payload, logPayload json.RawM
 		Description: good.Description,
 		Priority:    good.Priority,
 		Removed:     good.Removed,
+		Version:     good.Version,
+		Event:       natsLog.EventCreate,
 		EventTime:   time.Now(),
 	})
 	if err != nil {
@@ -145,25 +194,50 @@ func InsertGood(db *sql.DB, pID int, name string) (payload, logPayload json.RawM
 	return payload, logPayload, nil
 }
 
-// DeleteGood помечаем товар удаленным
-func DeleteGood(db *sql.DB, ID, pID int) (payload, logPayload json.RawMessage, err error) {
-	res, err := db.Exec("update test_issue.goods set removed = true where id = $1 and project_id = $2", ID, pID)
+// DeleteGood помечаем товар удаленным, version - ожидаемая версия товара или AnyVersion
+func DeleteGood(db *sql.DB, ID, pID, version int) (payload, logPayload json.RawMessage, err error) {
+	return setGoodRemoved(db, ID, pID, version, true)
+}
+
+// RestoreGood восстанавливаем удаленный товар, version - ожидаемая версия товара или AnyVersion
+func RestoreGood(db *sql.DB, ID, pID, version int) (payload, logPayload json.RawMessage, err error) {
+	return setGoodRemoved(db, ID, pID, version, false)
+}
+
+// removedEvent событие для лога при пометке удаленным или ее снятии
+func removedEvent(removed bool) string {
+	if removed {
+		return natsLog.EventRemove
+	}
+	return natsLog.EventRestore
+}
+
+// setGoodRemoved помечаем товар удаленным или снимаем пометку
+func setGoodRemoved(db *sql.DB, ID, pID, version int, removed bool) (payload, logPayload json.RawMessage, err error) {
+	tx, err := db.Begin()
 	if err != nil {
 		return nil, nil, err
 	}
-
-	rows, err := res.RowsAffected()
+	err = lockVersion(tx, ID, pID, version)
 	if err != nil {
+		tx.Rollback()
 		return nil, nil, err
 	}
-	if rows == 0 {
-		return []byte(notFoundMessage), nil, ErrNotFound
+	err = tx.QueryRow("update test_issue.goods set removed = $3, removed_at = "+removedAt+", version = version + 1 where id = $1 and project_id = $2 returning version", ID, pID, removed).Scan(&version)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, nil, err
 	}
 
 	payload, err = json.Marshal(Good{
 		ID:        ID,
 		ProjectID: pID,
-		Removed:   true,
+		Removed:   removed,
+		Version:   version,
 	})
 	if err != nil {
 		return nil, nil, err
@@ -171,7 +245,9 @@ func DeleteGood(db *sql.DB, ID, pID int) (payload, logPayload json.RawMessage, e
 	logPayload, err = json.Marshal(natsLog.LogMessage{
 		ID:        ID,
 		ProjectID: pID,
-		Removed:   true,
+		Removed:   removed,
+		Version:   version,
+		Event:     removedEvent(removed),
 		EventTime: time.Now(),
 	})
 	if err != nil {
@@ -180,47 +256,33 @@ func DeleteGood(db *sql.DB, ID, pID int) (payload, logPayload json.RawMessage, e
 	return payload, logPayload, nil
 }
 
-// UpdateGood обновляем товар
-func UpdateGood(db *sql.DB, ID, pID int, name, description string) (payload, logPayload json.RawMessage, err error) {
-	desc := ""
-	if description != "" {
-		desc = ", description = $4"
-	}
-	statement := fmt.Sprintf("UPDATE test_issue.goods SET name = $3 %s WHERE id = $1 and project_id = $2 returning *;", desc)
-
-	tx, err := db.Begin()
+// UpdateGood обновляем товар по merge patch, пустой патч ничего не меняет и не пишется в лог.
+// version - ожидаемая версия товара или AnyVersion
+func UpdateGood(db *sql.DB, ID, pID, version int, patch GoodPatch) (payload, logPayload json.RawMessage, err error) {
+	err = patch.Validate()
 	if err != nil {
 		return nil, nil, err
 	}
-	stmt, err := tx.Prepare(`SELECT * FROM test_issue.goods WHERE id = $1 and project_id = $2 FOR UPDATE;`)
-	if err != nil {
-		tx.Rollback()
-		return nil, nil, err
-	}
-	res, err := stmt.Exec(ID, pID)
+	set, args := patch.assignments(2)
+
+	tx, err := db.Begin()
 	if err != nil {
-		tx.Rollback()
 		return nil, nil, err
 	}
-
-	rows, err := res.RowsAffected()
+	err = lockVersion(tx, ID, pID, version)
 	if err != nil {
 		tx.Rollback()
 		return nil, nil, err
 	}
-	if rows == 0 {
-		tx.Rollback()
-		return []byte(notFoundMessage), nil, ErrNotFound
-	}
 
-	stmt, err = tx.Prepare(statement)
-	if err != nil {
-		tx.Rollback()
-		return nil, nil, err
+	if set != "" {
+		_, err = tx.Exec("UPDATE test_issue.goods SET "+set+", version = version + 1 WHERE id = $1 and project_id = $2;", append([]any{ID, pID}, args...)...)
+		if err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
 	}
-	updated := stmt.QueryRow(ID, pID, name, description)
-	good := Good{}
-	err = updated.Scan(&good.ID, &good.ProjectID, &good.Name, &good.Description, &good.Priority, &good.Removed, &good.CreatedAt)
+	good, err := findGood(tx, ID, pID)
 	if err != nil {
 		tx.Rollback()
 		return nil, nil, err
@@ -235,6 +297,9 @@ func UpdateGood(db *sql.DB, ID, pID int, name, description string) (payload, log
 	if err != nil {
 		return nil, nil, err
 	}
+	if patch.Empty() {
+		return payload, nil, nil
+	}
 	logPayload, err = json.Marshal(natsLog.LogMessage{
 		ID:          good.ID,
 		ProjectID:   pID,
@@ -242,6 +307,8 @@ func UpdateGood(db *sql.DB, ID, pID int, name, description string) (payload, log
 		Description: good.Description,
 		Priority:    good.Priority,
 		Removed:     good.Removed,
+		Version:     good.Version,
+		Event:       natsLog.EventUpdate,
 		EventTime:   time.Now(),
 	})
 	if err != nil {
@@ -250,81 +317,54 @@ func UpdateGood(db *sql.DB, ID, pID int, name, description string) (payload, log
 	return payload, logPayload, nil
 }
 
-// ReprioritiizeGood меняем приоритет у товара
-func ReprioritiizeGood(db *sql.DB, ID, pID, priority int) (payload json.RawMessage, logPayload []natsLog.LogMessage, err error) {
-	goods := []Good{}
+// ReprioritiizeGood перемещаем товар на позицию, до или после другого товара, в начало или в конец.
+// Меняется ранг только самого товара, позиции остальных товаров проекта сдвигаются сами,
+// поэтому в ответе и в логе только он. version - ожидаемая версия товара или AnyVersion
+func ReprioritiizeGood(db *sql.DB, ID, pID, version int, move Move) (payload json.RawMessage, logPayload []natsLog.LogMessage, err error) {
 	tx, err := db.Begin()
 	if err != nil {
 		return nil, nil, err
 	}
-	// в два подхода блокируем нужные записи в таблице
-	stmt, err := tx.Prepare(`SELECT * FROM test_issue.goods WHERE id = $1 and project_id = $2 FOR UPDATE;`)
-	if err != nil {
-		tx.Rollback()
-		return nil, nil, err
-	}
-	res, err := stmt.Exec(ID, pID)
-	if err != nil {
-		tx.Rollback()
-		return nil, nil, err
-	}
-	rowsA, err := res.RowsAffected()
-	if err != nil {
-		tx.Rollback()
-		return nil, nil, err
-	}
-	if rowsA == 0 {
-		tx.Rollback()
-		return []byte(notFoundMessage), nil, ErrNotFound
-	}
-	stmt, err = tx.Prepare(`SELECT * FROM test_issue.goods WHERE priority >= $1 FOR UPDATE;`)
+	// блокируем проект, чтобы ранги в нем менял только один запрос
+	_, err = lockProject(tx, pID)
 	if err != nil {
 		tx.Rollback()
+		// без проекта нет и товара
+		if errors.Is(err, ErrProjectNotFound) {
+			return nil, nil, ErrNotFound
+		}
 		return nil, nil, err
 	}
-	_, err = stmt.Exec(priority)
+	err = lockVersion(tx, ID, pID, version)
 	if err != nil {
 		tx.Rollback()
 		return nil, nil, err
 	}
 
-	stmt, err = tx.Prepare(`UPDATE test_issue.goods SET priority = priority+1 WHERE priority >= $1 returning id, priority;`)
+	// соседи определяются внутри транзакции, поэтому перемещение не гоняется с другими
+	err = placeGood(tx, ID, pID, func() (prev, next *int64, err error) {
+		return move.neighbours(tx, ID, pID)
+	})
 	if err != nil {
 		tx.Rollback()
 		return nil, nil, err
 	}
-	rows, err := stmt.Query(priority)
+	_, err = tx.Exec("update test_issue.goods set version = version + 1 where id = $1 and project_id = $2", ID, pID)
 	if err != nil {
 		tx.Rollback()
 		return nil, nil, err
 	}
-	for rows.Next() {
-		good := Good{}
-		err = rows.Scan(&good.ID, &good.Priority)
-		if err != nil {
-			return nil, nil, err
-		}
-		goods = append(goods, good)
-	}
-
-	stmt, err = tx.Prepare(`UPDATE test_issue.goods SET priority = $3 WHERE id = $1 and project_id = $2 returning id, priority;`)
-	if err != nil {
-		tx.Rollback()
-		return nil, nil, err
-	}
-	row := stmt.QueryRow(ID, pID, priority)
-	good := Good{}
-	err = row.Scan(&good.ID, &good.Priority)
+	good, err := findGood(tx, ID, pID)
 	if err != nil {
 		tx.Rollback()
 		return nil, nil, err
 	}
-	goods = append(goods, good)
 	err = tx.Commit()
 	if err != nil {
 		return nil, nil, err
 	}
 
+	goods := []Good{{ID: good.ID, ProjectID: good.ProjectID, Priority: good.Priority, Version: good.Version}}
 	payload, err = json.Marshal(ReprioritiizeResponse{Priorities: goods})
 	if err != nil {
 		return nil, nil, err
@@ -332,15 +372,15 @@ func ReprioritiizeGood(db *sql.DB, ID, pID, priority int) (payload json.RawMessa
 	for _, g := range goods {
 		logPayload = append(logPayload, natsLog.LogMessage{
 			ID:        g.ID,
+			ProjectID: g.ProjectID,
 			Priority:  g.Priority,
+			Version:   g.Version,
+			Event:     natsLog.EventReprioritize,
 			EventTime: time.Now(),
 		})
 	}
 	return payload, logPayload, nil
 }
 
-// notFoundMessage сообщение если товар не найден
-var notFoundMessage = `"code": 3 "message": "errors.common.notFound" "details": {}`
-
 // ErrNotFound сообщение если товар не найден
 var ErrNotFound = errors.New("good not found")
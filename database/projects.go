@@ -0,0 +1,160 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Project структура проекта
+type Project struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
+
+// ProjectsResponse структура ответа для списка проектов
+type ProjectsResponse struct {
+	Projects []Project `json:"projects"`
+}
+
+// FindProjects получаем все проекты
+func FindProjects(db *sql.DB) (payload json.RawMessage, err error) {
+	projects, err := queryProjects(db, "select id, name, created_at from test_issue.projects order by id")
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := json.Marshal(ProjectsResponse{Projects: projects})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FindProjectsPage limit проектов по порядку id после первых offset
+func FindProjectsPage(db *sql.DB, limit, offset int) ([]Project, error) {
+	return queryProjects(db, "select id, name, created_at from test_issue.projects order by id limit $1 offset $2", limit, max(offset, 0))
+}
+
+// FindProject получаем проект по id
+func FindProject(db *sql.DB, ID int) (project Project, err error) {
+	err = db.QueryRow("select id, name, created_at from test_issue.projects where id = $1", ID).Scan(&project.ID, &project.Name, &project.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return project, ErrProjectNotFound
+	}
+	return project, err
+}
+
+// queryProjects проекты по запросу с колонками id, name, created_at
+func queryProjects(db *sql.DB, query string, args ...any) ([]Project, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []Project{}
+	for rows.Next() {
+		project := Project{}
+		err = rows.Scan(&project.ID, &project.Name, &project.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+	return projects, rows.Err()
+}
+
+// InsertProject добавляем проект
+func InsertProject(db *sql.DB, name string) (payload json.RawMessage, err error) {
+	row := db.QueryRow("insert into test_issue.projects (name) values($1) returning id, name, created_at", name)
+	project := Project{}
+	err = row.Scan(&project.ID, &project.Name, &project.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := json.Marshal(project)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RenameProject переименовываем проект
+func RenameProject(db *sql.DB, ID int, name string) (payload json.RawMessage, err error) {
+	row := db.QueryRow("update test_issue.projects set name = $2 where id = $1 returning id, name, created_at", ID, name)
+	project := Project{}
+	err = row.Scan(&project.ID, &project.Name, &project.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := json.Marshal(project)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteProject удаляем проект, если в нем нет товаров
+func DeleteProject(db *sql.DB, ID int) (payload json.RawMessage, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	// блокируем проект, чтобы в него не добавили товар пока удаляем
+	_, err = lockProject(tx, ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	hasGoods := false
+	err = tx.QueryRow("select exists(select 1 from test_issue.goods where project_id = $1)", ID).Scan(&hasGoods)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if hasGoods {
+		tx.Rollback()
+		return nil, ErrProjectNotEmpty
+	}
+
+	_, err = tx.Exec("delete from test_issue.projects where id = $1", ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := json.Marshal(Project{ID: ID})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// lockProject блокируем строку проекта до конца транзакции
+func lockProject(tx *sql.Tx, ID int) (project Project, err error) {
+	row := tx.QueryRow("select id, name, created_at from test_issue.projects where id = $1 for update", ID)
+	err = row.Scan(&project.ID, &project.Name, &project.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return project, ErrProjectNotFound
+	}
+	return project, err
+}
+
+// ErrProjectNotFound сообщение если проект не найден
+var ErrProjectNotFound = errors.New("project not found")
+
+// ErrProjectNotEmpty сообщение если в проекте есть товары
+var ErrProjectNotEmpty = errors.New("project has goods")
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	natsLog "main/nats"
+	"time"
+)
+
+// RetentionConfig сколько хранить удаленные товары до окончательного удаления
+type RetentionConfig struct {
+	// RemovedDays через сколько дней после удаления товар удаляется из БД, 0 - никогда
+	RemovedDays int `yaml:"removedDays"`
+	// Interval как часто запускать очистку
+	Interval time.Duration `yaml:"interval"`
+}
+
+// removedAt выражение для removed_at при смене removed на $3: время первого удаления сохраняется
+const removedAt = "case when $3 then coalesce(removed_at, now()) end"
+
+// PurgeResponse структура ответа для окончательного удаления
+type PurgeResponse struct {
+	Purged []Good `json:"purged"`
+}
+
+// PurgeExpired окончательно удаляем товары, помеченные удаленными раньше before. Каждый проект
+// очищается в своей транзакции под блокировкой, как при перемещении товаров. Если проект не удалось
+// очистить, возвращаем ошибку вместе с событиями уже очищенных проектов
+func PurgeExpired(db *sql.DB, before time.Time) (payload json.RawMessage, logPayload []natsLog.LogMessage, err error) {
+	projectIDs, err := expiredProjects(db, before)
+	if err != nil {
+		return nil, nil, err
+	}
+	res := PurgeResponse{Purged: []Good{}}
+	for _, pID := range projectIDs {
+		purged, msgs, err := purgeProject(db, pID, before)
+		if errors.Is(err, ErrProjectNotFound) {
+			// проект удалили, пока очищали остальные
+			continue
+		}
+		if err != nil {
+			return nil, logPayload, err
+		}
+		res.Purged = append(res.Purged, purged...)
+		logPayload = append(logPayload, msgs...)
+	}
+	payload, err = json.Marshal(res)
+	if err != nil {
+		return nil, logPayload, err
+	}
+	return payload, logPayload, nil
+}
+
+// PurgeProject окончательно удаляем все помеченные удаленными товары проекта
+func PurgeProject(db *sql.DB, pID int) (payload json.RawMessage, logPayload []natsLog.LogMessage, err error) {
+	purged, logPayload, err := purgeProject(db, pID, time.Time{})
+	if err != nil {
+		return nil, nil, err
+	}
+	payload, err = json.Marshal(PurgeResponse{Purged: purged})
+	if err != nil {
+		return nil, nil, err
+	}
+	return payload, logPayload, nil
+}
+
+// expiredProjects проекты, в которых есть товары, помеченные удаленными раньше before
+func expiredProjects(db *sql.DB, before time.Time) ([]int, error) {
+	rows, err := db.Query("select distinct project_id from test_issue.goods where removed and removed_at < $1 order by project_id", before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	IDs := []int{}
+	for rows.Next() {
+		ID := 0
+		if err = rows.Scan(&ID); err != nil {
+			return nil, err
+		}
+		IDs = append(IDs, ID)
+	}
+	return IDs, rows.Err()
+}
+
+// purgeProject удаляем помеченные удаленными товары проекта под его блокировкой: ранги в проекте
+// в это время не меняет никто другой. Нулевой before - все помеченные удаленными товары
+func purgeProject(db *sql.DB, pID int, before time.Time) (purged []Good, logPayload []natsLog.LogMessage, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	_, err = lockProject(tx, pID)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	var removedBefore any
+	if !before.IsZero() {
+		removedBefore = before
+	}
+	rows, err := tx.Query(`delete from test_issue.goods
+		where project_id = $1 and removed and ($2::timestamptz is null or removed_at < $2)
+		returning id`, pID, removedBefore)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	purged = []Good{}
+	now := time.Now()
+	for rows.Next() {
+		good := Good{ProjectID: pID}
+		if err = rows.Scan(&good.ID); err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
+		purged = append(purged, good)
+		logPayload = append(logPayload, natsLog.LogMessage{
+			ID:        good.ID,
+			ProjectID: pID,
+			Removed:   true,
+			Purged:    true,
+			Event:     natsLog.EventPurge,
+			EventTime: now,
+		})
+	}
+	if err = rows.Err(); err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, nil, err
+	}
+	return purged, logPayload, nil
+}
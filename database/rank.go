@@ -0,0 +1,451 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	natsLog "main/nats"
+	"math"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// rankStep шаг между рангами соседних товаров при добавлении и перебалансировке.
+// Порядок товаров в проекте задается рангом, а приоритет (позиция) вычисляется
+// по нему при чтении, поэтому перемещение товара обычно меняет одну строку.
+const rankStep = 1 << 16
+
+// ErrNoRankGap сообщение если между соседями не нашлось места даже после перебалансировки
+var ErrNoRankGap = errors.New("no room between ranks")
+
+// queryRower общий интерфейс для *sql.DB и *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// execer общий интерфейс для *sql.DB и *sql.Tx
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// goodPriority позиция товара g в проекте: сколько товаров проекта стоит не дальше него по (rank, id).
+// Считается по индексу goods_rank_idx для каждой строки отдельно, поэтому строки нужно отобрать до нее
+const goodPriority = "(select count(*) from test_issue.goods o where o.project_id = g.project_id and (o.rank, o.id) <= (g.rank, g.id))::integer"
+
+// goodColumns колонки test_issue.goods g в порядке полей Good
+const goodColumns = "g.id, g.project_id, g.name, g.description, " + goodPriority + ", g.removed, g.created_at, g.version, g.rank"
+
+// scanGood читаем товар из строки с колонками goodColumns
+func scanGood(row interface{ Scan(...any) error }) (good Good, err error) {
+	err = row.Scan(&good.ID, &good.ProjectID, &good.Name, &good.Description, &good.Priority, &good.Removed, &good.CreatedAt, &good.Version, &good.rank)
+	return good, err
+}
+
+// findGood читаем товар вместе с его позицией
+func findGood(q queryRower, ID, pID int) (Good, error) {
+	good, err := scanGood(q.QueryRow("select "+goodColumns+" from test_issue.goods g where g.id = $1 and g.project_id = $2", ID, pID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return good, ErrNotFound
+	}
+	return good, err
+}
+
+// neighboursAt ранги товаров, между которыми окажется товар ID на позиции position.
+// Позиция считается среди остальных товаров проекта и не выходит за их количество + 1.
+func neighboursAt(tx *sql.Tx, ID, pID, position int) (prev, next *int64, err error) {
+	others := 0
+	err = tx.QueryRow("select count(*) from test_issue.goods where project_id = $1 and id <> $2", pID, ID).Scan(&others)
+	if err != nil {
+		return nil, nil, err
+	}
+	position, offset := neighboursWindow(position, others)
+	rows, err := tx.Query("select rank from test_issue.goods where project_id = $1 and id <> $2 order by rank, id offset $3 limit 2", pID, ID, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	ranks := []int64{}
+	for rows.Next() {
+		var rank int64
+		if err = rows.Scan(&rank); err != nil {
+			return nil, nil, err
+		}
+		ranks = append(ranks, rank)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	prev, next = neighboursIn(position, ranks)
+	return prev, next, nil
+}
+
+// neighboursWindow приводим позицию к 1..others+1 и получаем offset первого из соседей среди остальных товаров
+func neighboursWindow(position, others int) (clamped, offset int) {
+	clamped = min(max(position, 1), others+1)
+	return clamped, max(clamped-2, 0)
+}
+
+// neighboursIn соседи позиции position из рангов, выбранных с offset из neighboursWindow
+func neighboursIn(position int, ranks []int64) (prev, next *int64) {
+	if position == 1 {
+		if len(ranks) > 0 {
+			next = &ranks[0]
+		}
+		return nil, next
+	}
+	if len(ranks) > 0 {
+		prev = &ranks[0]
+	}
+	if len(ranks) > 1 {
+		next = &ranks[1]
+	}
+	return prev, next
+}
+
+// rankBetween ранг между соседями, false если свободного места нет
+func rankBetween(prev, next *int64) (int64, bool) {
+	switch {
+	case prev == nil && next == nil:
+		return rankStep, true
+	case prev == nil:
+		return *next / 2, *next > 1
+	case next == nil:
+		return *prev + rankStep, true
+	default:
+		return *prev + (*next-*prev)/2, *next-*prev > 1
+	}
+}
+
+// placeGood ставим товар между соседями, которых возвращает neighbours.
+// Если места нет, ранги проекта перебалансируются и соседи запрашиваются заново.
+func placeGood(tx execer, ID, pID int, neighbours func() (prev, next *int64, err error)) error {
+	prev, next, err := neighbours()
+	if err != nil {
+		return err
+	}
+	rank, ok := rankBetween(prev, next)
+	if !ok {
+		if err = rebalanceRanks(tx, pID); err != nil {
+			return err
+		}
+		prev, next, err = neighbours()
+		if err != nil {
+			return err
+		}
+		if rank, ok = rankBetween(prev, next); !ok {
+			return ErrNoRankGap
+		}
+	}
+	_, err = tx.Exec("update test_issue.goods set rank = $3 where id = $1 and project_id = $2", ID, pID, rank)
+	return err
+}
+
+// applyOrder раздаем товарам проекта ранги в порядке order. positions - прежние позиции,
+// в ответе товары, у которых позиция поменялась, с новыми позициями и текущими версиями
+func applyOrder(tx *sql.Tx, pID int, order []int, positions map[int]int) (moved []Good, err error) {
+	rows, err := tx.Query(`update test_issue.goods g set rank = v.pos * $3
+		from unnest($2::integer[]) with ordinality as v(id, pos)
+		where g.id = v.id and g.project_id = $1
+		returning g.id, g.version`, pID, pq.Array(order), rankStep)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	versions := make(map[int]int, len(order))
+	for rows.Next() {
+		ID, version := 0, 0
+		if err = rows.Scan(&ID, &version); err != nil {
+			return nil, err
+		}
+		versions[ID] = version
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	moved = []Good{}
+	for i, ID := range order {
+		if positions[ID] != i+1 {
+			moved = append(moved, Good{ID: ID, ProjectID: pID, Priority: i + 1, Version: versions[ID]})
+		}
+	}
+	return moved, nil
+}
+
+// bumpVersions повышаем версию товаров goods, в goods записываются новые версии
+func bumpVersions(tx *sql.Tx, pID int, goods []Good) error {
+	if len(goods) == 0 {
+		return nil
+	}
+	index := make(map[int]int, len(goods))
+	IDs := make([]int, 0, len(goods))
+	for i, g := range goods {
+		index[g.ID] = i
+		IDs = append(IDs, g.ID)
+	}
+	rows, err := tx.Query("update test_issue.goods set version = version + 1 where project_id = $1 and id = any($2) returning id, version", pID, pq.Array(IDs))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		ID, version := 0, 0
+		if err = rows.Scan(&ID, &version); err != nil {
+			return err
+		}
+		goods[index[ID]].Version = version
+	}
+	return rows.Err()
+}
+
+// rebalanceRanks равномерно раздаем ранги товарам проекта, порядок и позиции не меняются
+func rebalanceRanks(tx execer, pID int) error {
+	_, err := tx.Exec(`update test_issue.goods g set rank = r.pos * $2
+		from (select id, row_number() over (order by rank, id) as pos from test_issue.goods where project_id = $1) r
+		where g.id = r.id and g.project_id = $1`, pID, rankStep)
+	return err
+}
+
+// RebalanceRanks равномерно раздаем ранги проекта, при pID = 0 всех проектов. Порядок и позиции
+// не меняются, поэтому кеш и лог не трогаем. Каждый проект перебалансируется в своей транзакции под блокировкой
+func RebalanceRanks(db *sql.DB, pID int) error {
+	projectIDs, err := priorityProjects(db, pID)
+	if err != nil {
+		return err
+	}
+	for _, ID := range projectIDs {
+		err = rebalanceProject(db, ID)
+		if errors.Is(err, ErrProjectNotFound) && pID == 0 {
+			// проект удалили, пока перебалансировали остальные
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rebalanceProject перебалансировка рангов одного проекта
+func rebalanceProject(db *sql.DB, pID int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = lockProject(tx, pID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = rebalanceRanks(tx, pID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Move куда переместить товар: на позицию, до или после другого товара, в начало или в конец
+type Move struct {
+	Priority int
+	Before   int
+	After    int
+	Position string
+}
+
+// позиции для Move.Position
+const (
+	PositionTop    = "top"
+	PositionBottom = "bottom"
+)
+
+// ErrBadMove сообщение если перемещение задано неверно
+var ErrBadMove = errors.New("exactly one of priority, before, after or position=top|bottom must be provided")
+
+// ErrAnchorNotFound сообщение если товар, относительно которого перемещаем, не найден в проекте
+var ErrAnchorNotFound = errors.New("anchor good not found")
+
+// Validate проверяем, что задан ровно один способ перемещения
+func (m Move) Validate() error {
+	set := 0
+	if m.Priority != 0 {
+		set++
+		if m.Priority < 0 {
+			return ErrBadMove
+		}
+	}
+	if m.Before != 0 {
+		set++
+	}
+	if m.After != 0 {
+		set++
+	}
+	if m.Position != "" {
+		set++
+		if m.Position != PositionTop && m.Position != PositionBottom {
+			return ErrBadMove
+		}
+	}
+	if set != 1 {
+		return ErrBadMove
+	}
+	return nil
+}
+
+// neighbours соседи для перемещения товара ID согласно m
+func (m Move) neighbours(tx *sql.Tx, ID, pID int) (prev, next *int64, err error) {
+	switch {
+	case m.Before != 0:
+		return neighboursAround(tx, ID, pID, m.Before, false)
+	case m.After != 0:
+		return neighboursAround(tx, ID, pID, m.After, true)
+	case m.Position == PositionTop:
+		return neighboursAt(tx, ID, pID, 1)
+	case m.Position == PositionBottom:
+		return neighboursAt(tx, ID, pID, math.MaxInt32)
+	default:
+		return neighboursAt(tx, ID, pID, m.Priority)
+	}
+}
+
+// neighboursAround ранги соседей для товара ID, который ставим до или после товара anchorID
+func neighboursAround(tx *sql.Tx, ID, pID, anchorID int, after bool) (prev, next *int64, err error) {
+	if anchorID == ID {
+		return nil, nil, ErrBadMove
+	}
+	var anchor int64
+	err = tx.QueryRow("select rank from test_issue.goods where id = $1 and project_id = $2", anchorID, pID).Scan(&anchor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrAnchorNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query := "select rank from test_issue.goods where project_id = $1 and id <> $2 and (rank, id) < ($3, $4) order by rank desc, id desc limit 1"
+	if after {
+		query = "select rank from test_issue.goods where project_id = $1 and id <> $2 and (rank, id) > ($3, $4) order by rank, id limit 1"
+	}
+	var other int64
+	err = tx.QueryRow(query, pID, ID, anchor, anchorID).Scan(&other)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, err
+	}
+	found := err == nil
+	if after {
+		prev = &anchor
+		if found {
+			next = &other
+		}
+	} else {
+		next = &anchor
+		if found {
+			prev = &other
+		}
+	}
+	return prev, next, nil
+}
+
+// ErrBadReorder сообщение если в новом порядке есть повторяющиеся id
+var ErrBadReorder = errors.New("ids must be unique and not empty")
+
+// reorderSlots новый порядок проекта: позиции переданных товаров по порядку занимают товары из IDs
+func reorderSlots(ranked []rankedGood, IDs []int, listed map[int]bool) []int {
+	order := make([]int, len(ranked))
+	next := 0
+	for i, g := range ranked {
+		if listed[g.ID] {
+			order[i] = IDs[next]
+			next++
+			continue
+		}
+		order[i] = g.ID
+	}
+	return order
+}
+
+// ReorderGoods задаем новый порядок товаров проекта одной транзакцией. Переданные товары
+// переставляются в указанном порядке внутри позиций, которые они уже занимают, остальные остаются на месте.
+// В ответе и в логе только товары, у которых поменялась позиция, их версия повышается
+func ReorderGoods(db *sql.DB, pID int, IDs []int) (payload json.RawMessage, logPayload []natsLog.LogMessage, err error) {
+	if len(IDs) == 0 {
+		return nil, nil, ErrBadReorder
+	}
+	listed := make(map[int]bool, len(IDs))
+	for _, ID := range IDs {
+		if listed[ID] {
+			return nil, nil, ErrBadReorder
+		}
+		listed[ID] = true
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	_, err = lockProject(tx, pID)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	ranked, err := loadRanked(tx, pID)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	positions := make(map[int]int, len(ranked))
+	for i, g := range ranked {
+		positions[g.ID] = i + 1
+	}
+
+	missing := []int{}
+	for _, ID := range IDs {
+		if _, ok := positions[ID]; !ok {
+			missing = append(missing, ID)
+		}
+	}
+	if len(missing) > 0 {
+		tx.Rollback()
+		out, err := json.Marshal(map[string]any{"ids": missing})
+		if err != nil {
+			return nil, nil, err
+		}
+		return out, nil, ErrNotFound
+	}
+
+	goods, err := applyOrder(tx, pID, reorderSlots(ranked, IDs, listed), positions)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	// переставленные товары меняются по запросу, поэтому их версия растет, как при смене приоритета
+	err = bumpVersions(tx, pID, goods)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payload, err = json.Marshal(ReprioritiizeResponse{Priorities: goods})
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	for _, g := range goods {
+		logPayload = append(logPayload, natsLog.LogMessage{
+			ID:        g.ID,
+			ProjectID: g.ProjectID,
+			Priority:  g.Priority,
+			Version:   g.Version,
+			Event:     natsLog.EventReprioritize,
+			EventTime: now,
+		})
+	}
+	return payload, logPayload, nil
+}
@@ -0,0 +1,234 @@
+package database
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// rank указатель на ранг для соседей в тестах
+func rank(r int64) *int64 {
+	return &r
+}
+
+// TestRankBetween ранг посередине между соседями и отказ, если места нет
+func TestRankBetween(t *testing.T) {
+	tests := []struct {
+		name       string
+		prev, next *int64
+		want       int64
+		ok         bool
+	}{
+		{name: "empty project", want: rankStep, ok: true},
+		{name: "top", next: rank(rankStep), want: rankStep / 2, ok: true},
+		{name: "top without room", next: rank(1), ok: false},
+		{name: "bottom", prev: rank(3 * rankStep), want: 4 * rankStep, ok: true},
+		{name: "between", prev: rank(rankStep), next: rank(2 * rankStep), want: rankStep + rankStep/2, ok: true},
+		{name: "between odd gap", prev: rank(10), next: rank(13), want: 11, ok: true},
+		{name: "adjacent ranks", prev: rank(10), next: rank(11), ok: false},
+		{name: "equal ranks", prev: rank(10), next: rank(10), ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := rankBetween(tt.prev, tt.next)
+			if ok != tt.ok || (ok && got != tt.want) {
+				t.Errorf("rankBetween() = %d, %v, want %d, %v", got, ok, tt.want, tt.ok)
+			}
+			if ok && ((tt.prev != nil && got <= *tt.prev) || (tt.next != nil && got >= *tt.next)) {
+				t.Errorf("rankBetween() = %d is not between the neighbours", got)
+			}
+		})
+	}
+}
+
+// TestNeighboursAt соседи позиции среди остальных товаров проекта, позиция вне диапазона прижимается к краю
+func TestNeighboursAt(t *testing.T) {
+	// ранги остальных товаров проекта по порядку
+	others := []int64{100, 200, 300}
+	tests := []struct {
+		name       string
+		position   int
+		prev, next *int64
+	}{
+		{name: "top", position: 1, next: rank(100)},
+		{name: "below top", position: 2, prev: rank(100), next: rank(200)},
+		{name: "middle", position: 3, prev: rank(200), next: rank(300)},
+		{name: "bottom", position: 4, prev: rank(300)},
+		{name: "past bottom", position: 100, prev: rank(300)},
+		{name: "before top", position: -5, next: rank(100)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			position, offset := neighboursWindow(tt.position, len(others))
+			// то же, что вернет запрос с offset и limit 2
+			ranks := others[min(offset, len(others)):min(offset+2, len(others))]
+			prev, next := neighboursIn(position, ranks)
+			if !sameRank(prev, tt.prev) || !sameRank(next, tt.next) {
+				t.Errorf("neighbours of %d = %s, %s, want %s, %s", tt.position, show(prev), show(next), show(tt.prev), show(tt.next))
+			}
+		})
+	}
+
+	position, offset := neighboursWindow(3, 0)
+	if prev, next := neighboursIn(position, nil); prev != nil || next != nil || offset != 0 {
+		t.Errorf("empty project: got %s, %s at offset %d, want no neighbours", show(prev), show(next), offset)
+	}
+}
+
+// fakeExec запоминает запросы вместо выполнения
+type fakeExec struct {
+	queries []string
+	args    [][]any
+}
+
+// Exec запоминаем запрос
+func (f *fakeExec) Exec(query string, args ...any) (sql.Result, error) {
+	f.queries = append(f.queries, query)
+	f.args = append(f.args, args)
+	return driver.RowsAffected(1), nil
+}
+
+// TestPlaceGood товар получает ранг между соседями, без места ранги перебалансируются и соседи запрашиваются заново
+func TestPlaceGood(t *testing.T) {
+	t.Run("room between neighbours", func(t *testing.T) {
+		tx := &fakeExec{}
+		err := placeGood(tx, 7, 1, func() (prev, next *int64, err error) {
+			return rank(100), rank(200), nil
+		})
+		if err != nil {
+			t.Fatalf("placeGood: %v", err)
+		}
+		if len(tx.queries) != 1 || tx.args[0][2] != int64(150) {
+			t.Errorf("queries %q with args %v, want one update to rank 150", tx.queries, tx.args)
+		}
+	})
+
+	t.Run("rebalance", func(t *testing.T) {
+		tx := &fakeExec{}
+		calls := 0
+		err := placeGood(tx, 7, 1, func() (prev, next *int64, err error) {
+			calls++
+			if calls == 1 {
+				return rank(100), rank(101), nil
+			}
+			return rank(rankStep), rank(2 * rankStep), nil
+		})
+		if err != nil {
+			t.Fatalf("placeGood: %v", err)
+		}
+		if calls != 2 || len(tx.queries) != 2 || !strings.Contains(tx.queries[0], "row_number") {
+			t.Fatalf("got %d neighbour calls and queries %q, want a rebalance and an update", calls, tx.queries)
+		}
+		if tx.args[1][2] != int64(rankStep+rankStep/2) {
+			t.Errorf("rank = %v, want %d", tx.args[1][2], rankStep+rankStep/2)
+		}
+	})
+
+	t.Run("no room after rebalance", func(t *testing.T) {
+		tx := &fakeExec{}
+		err := placeGood(tx, 7, 1, func() (prev, next *int64, err error) {
+			return rank(100), rank(101), nil
+		})
+		if !errors.Is(err, ErrNoRankGap) {
+			t.Errorf("placeGood() = %v, want %v", err, ErrNoRankGap)
+		}
+	})
+
+	t.Run("neighbours error", func(t *testing.T) {
+		tx := &fakeExec{}
+		errDB := errors.New("db is down")
+		err := placeGood(tx, 7, 1, func() (prev, next *int64, err error) {
+			return nil, nil, errDB
+		})
+		if !errors.Is(err, errDB) || len(tx.queries) != 0 {
+			t.Errorf("placeGood() = %v after %d queries, want %v and no queries", err, len(tx.queries), errDB)
+		}
+	})
+}
+
+// sameRank равны ли необязательные ранги
+func sameRank(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// show ранг для сообщения теста
+func show(r *int64) string {
+	if r == nil {
+		return "nil"
+	}
+	return strconv.FormatInt(*r, 10)
+}
+
+// TestAfterCursor курсор сортировки по приоритету продолжает выдачу по проекту, рангу и id
+func TestAfterCursor(t *testing.T) {
+	tests := []struct {
+		sort string
+		good Good
+		cond string
+		args []any
+	}{
+		{sort: "id", good: Good{ID: 5}, cond: "id > $3", args: []any{5}},
+		{sort: "-id", good: Good{ID: 5}, cond: "id < $3", args: []any{5}},
+		{sort: "priority", good: Good{ID: 5, ProjectID: 2, Priority: 40, rank: 7 * rankStep}, cond: "(project_id, rank, id) > ($3, $4, $5)", args: []any{2, int64(7 * rankStep), 5}},
+		{sort: "-priority", good: Good{ID: 5, ProjectID: 2, rank: 3}, cond: "(project_id, rank, id) < ($3, $4, $5)", args: []any{2, int64(3), 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			s, err := encodeCursor(tt.sort, tt.good)
+			if err != nil {
+				t.Fatalf("encodeCursor: %v", err)
+			}
+			cond, args, err := GoodsFilter{Sort: tt.sort}.afterCursor(s, 2)
+			if err != nil {
+				t.Fatalf("afterCursor: %v", err)
+			}
+			if cond != tt.cond || len(args) != len(tt.args) {
+				t.Fatalf("afterCursor() = %q %v, want %q %v", cond, args, tt.cond, tt.args)
+			}
+			for i := range args {
+				if args[i] != tt.args[i] {
+					t.Errorf("arg %d = %#v, want %#v", i, args[i], tt.args[i])
+				}
+			}
+		})
+	}
+
+	s, _ := encodeCursor("priority", Good{ID: 1})
+	if _, _, err := (GoodsFilter{Sort: "id"}).afterCursor(s, 0); !errors.Is(err, ErrBadCursor) {
+		t.Errorf("cursor of another sort: err = %v, want %v", err, ErrBadCursor)
+	}
+}
+
+// TestReorderSlots переданные товары меняются местами в своих позициях, остальные не двигаются
+func TestReorderSlots(t *testing.T) {
+	ranked := []rankedGood{{ID: 10}, {ID: 1}, {ID: 11}, {ID: 2}, {ID: 12}, {ID: 3}}
+	tests := []struct {
+		name string
+		IDs  []int
+		want []int
+	}{
+		{name: "partial", IDs: []int{3, 1, 2}, want: []int{10, 3, 11, 1, 12, 2}},
+		{name: "same order", IDs: []int{1, 2, 3}, want: []int{10, 1, 11, 2, 12, 3}},
+		{name: "single", IDs: []int{2}, want: []int{10, 1, 11, 2, 12, 3}},
+		{name: "whole project", IDs: []int{3, 2, 1, 12, 11, 10}, want: []int{3, 2, 1, 12, 11, 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listed := map[int]bool{}
+			for _, ID := range tt.IDs {
+				listed[ID] = true
+			}
+			got := reorderSlots(ranked, tt.IDs, listed)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("reorderSlots() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
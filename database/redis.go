@@ -2,8 +2,11 @@ package database
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -34,24 +37,112 @@ func GetRedisClient(cfg RedisConfig) (db *redis.Client, err error) {
 	return db, nil
 }
 
+// listKeyPrefix префикс ключей кеша для списков товаров
+const listKeyPrefix = "goods:"
+
+// listKey ключ кеша для списка товаров с учетом фильтра и страницы
+func listKey(filter GoodsFilter, page Page) string {
+	if page.Keyset {
+		return fmt.Sprintf("%s%d-c%s:%s", listKeyPrefix, page.Limit, page.Cursor, filter.CacheKey())
+	}
+	return fmt.Sprintf("%s%d-%d:%s", listKeyPrefix, page.Limit, page.Offset, filter.CacheKey())
+}
+
+// goodKey ключ кеша для отдельного товара
+func goodKey(ID, pID int) string {
+	return fmt.Sprintf("good:%d:%d", pID, ID)
+}
+
+// CachedPage страница списка вместе с валидаторами для HTTP-кеширования
+type CachedPage struct {
+	Payload      json.RawMessage
+	ETag         string
+	LastModified time.Time
+}
+
+// NewCachedPage считаем ETag по содержимому страницы, временем изменения считаем момент выборки
+func NewCachedPage(payload json.RawMessage) CachedPage {
+	sum := sha256.Sum256(payload)
+	return CachedPage{
+		Payload:      payload,
+		ETag:         `"` + hex.EncodeToString(sum[:]) + `"`,
+		LastModified: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
 // FindInCache ищем в кеше
-func FindInCache(db *redis.Client, limit, offset int) (payload json.RawMessage, err error) {
+func FindInCache(db *redis.Client, filter GoodsFilter, page Page) (cached CachedPage, err error) {
 	ctx := context.Background()
-	res, err := db.Get(ctx, fmt.Sprintf("%d-%d", limit, offset)).Bytes()
+	res, err := db.HGetAll(ctx, listKey(filter, page)).Result()
+	if err != nil {
+		return cached, err
+	}
+	if len(res) == 0 {
+		return cached, redis.Nil
+	}
+	modified, err := strconv.ParseInt(res["modified"], 10, 64)
+	if err != nil {
+		return cached, err
+	}
+	return CachedPage{
+		Payload:      json.RawMessage(res["payload"]),
+		ETag:         res["etag"],
+		LastModified: time.Unix(modified, 0).UTC(),
+	}, nil
+}
+
+// PutInCache записываем в кеш страницу вместе с ее ETag и временем изменения
+func PutInCache(db *redis.Client, cached CachedPage, filter GoodsFilter, page Page) (err error) {
+	ctx := context.Background()
+	key := listKey(filter, page)
+	_, err = db.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, "payload", string(cached.Payload), "etag", cached.ETag, "modified", cached.LastModified.Unix())
+		pipe.Expire(ctx, key, time.Minute)
+		return nil
+	})
+	return err
+}
+
+// InvalidateCache ивалидируем кеш списков, кеш отдельных товаров не трогаем
+func InvalidateCache(db *redis.Client) error {
+	return deleteByPattern(db, listKeyPrefix+"*")
+}
+
+// deleteByPattern удаляем ключи по шаблону
+func deleteByPattern(db *redis.Client, pattern string) error {
+	ctx := context.Background()
+	iter := db.Scan(ctx, 0, pattern, 0).Iterator()
+	for iter.Next(ctx) {
+		if err := db.Del(ctx, iter.Val()).Err(); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+// FindGoodInCache ищем товар в кеше
+func FindGoodInCache(db *redis.Client, ID, pID int) (payload json.RawMessage, err error) {
+	ctx := context.Background()
+	res, err := db.Get(ctx, goodKey(ID, pID)).Bytes()
 	if err != nil {
 		return nil, err
 	}
 	return res, nil
 }
 
-// PutInCache записываем в кеш
-func PutInCache(db *redis.Client, payload json.RawMessage, limit, offset int) (err error) {
+// PutGoodInCache записываем товар в кеш
+func PutGoodInCache(db *redis.Client, payload json.RawMessage, ID, pID int) (err error) {
 	ctx := context.Background()
-	return db.Set(ctx, fmt.Sprintf("%d-%d", limit, offset), string(payload), time.Minute).Err()
+	return db.Set(ctx, goodKey(ID, pID), string(payload), time.Minute).Err()
 }
 
-// InvalidateCache ивалидируем кеш
-func InvalidateCache(db *redis.Client) error {
+// InvalidateProjectGoodsCache инвалидируем кеш всех отдельных товаров проекта
+func InvalidateProjectGoodsCache(db *redis.Client, pID int) error {
+	return deleteByPattern(db, fmt.Sprintf("good:%d:*", pID))
+}
+
+// InvalidateGoodCache инвалидируем кеш отдельного товара
+func InvalidateGoodCache(db *redis.Client, ID, pID int) error {
 	ctx := context.Background()
-	return db.FlushDB(ctx).Err()
+	return db.Del(ctx, goodKey(ID, pID)).Err()
 }
@@ -0,0 +1,28 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// ErrVersionMismatch сообщение если товар успели изменить, версия из If-Match устарела
+var ErrVersionMismatch = errors.New("good version mismatch")
+
+// AnyVersion версия, с которой совпадает любая версия товара
+const AnyVersion = 0
+
+// lockVersion блокируем товар до конца транзакции и сверяем его версию с ожидаемой
+func lockVersion(tx *sql.Tx, ID, pID, version int) error {
+	current := 0
+	err := tx.QueryRow("select version from test_issue.goods where id = $1 and project_id = $2 for update", ID, pID).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if version != AnyVersion && version != current {
+		return ErrVersionMismatch
+	}
+	return nil
+}
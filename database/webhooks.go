@@ -0,0 +1,437 @@
+package database
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	natsLog "main/nats"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Webhook подписка проекта на события товаров. Secret отдается только при создании
+type Webhook struct {
+	ID        int        `json:"id"`
+	ProjectID int        `json:"projectId"`
+	URL       string     `json:"url"`
+	Secret    string     `json:"secret,omitempty"`
+	Events    []string   `json:"events"`
+	Active    bool       `json:"active"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
+
+// WebhooksResponse структура ответа для списка подписок
+type WebhooksResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+// NewWebhook данные для создания подписки. Пустой Events - все события,
+// без Secret ключ подписи генерируется
+type NewWebhook struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+// WebhookPatch изменения подписки, отсутствующие поля не меняются
+type WebhookPatch struct {
+	URL    *string   `json:"url"`
+	Events *[]string `json:"events"`
+	Active *bool     `json:"active"`
+}
+
+// статусы доставки
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+	// DeliverySkipped подписку выключили раньше, чем доставка ушла
+	DeliverySkipped = "skipped"
+)
+
+// Delivery доставка одного события подписке, она же запись журнала доставок
+type Delivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      int             `json:"webhookId"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus *int            `json:"responseStatus,omitempty"`
+	LastError      *string         `json:"lastError,omitempty"`
+	NextAttemptAt  *time.Time      `json:"nextAttemptAt,omitempty"`
+	CreatedAt      *time.Time      `json:"createdAt,omitempty"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty"`
+	// URL, Secret и Active подписки нужны только для отправки
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+	Active bool   `json:"-"`
+}
+
+// DeliveriesResponse структура ответа для журнала доставок
+type DeliveriesResponse struct {
+	Deliveries []Delivery `json:"deliveries"`
+}
+
+// DeliveryResult итог попытки доставки. RetryIn - через сколько повторить доставку в статусе pending
+type DeliveryResult struct {
+	Status         string
+	ResponseStatus int
+	Error          string
+	RetryIn        time.Duration
+}
+
+// ошибки подписок
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("delivery not found")
+	ErrWebhookInactive  = errors.New("webhook is inactive")
+	ErrBadWebhookURL    = errors.New("url must be an absolute http or https URL")
+	ErrBadWebhookEvent  = errors.New("unknown event")
+)
+
+// webhookEvents события, на которые можно подписаться
+var webhookEvents = []string{
+	natsLog.EventCreate,
+	natsLog.EventUpdate,
+	natsLog.EventRemove,
+	natsLog.EventRestore,
+	natsLog.EventReprioritize,
+	natsLog.EventPurge,
+}
+
+// validateWebhookURL адрес должен быть абсолютным http или https
+func validateWebhookURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrBadWebhookURL
+	}
+	return nil
+}
+
+// validateWebhookEvents все события должны быть из webhookEvents
+func validateWebhookEvents(events []string) error {
+	for _, event := range events {
+		if !slices.Contains(webhookEvents, event) {
+			return fmt.Errorf("%w: %s", ErrBadWebhookEvent, event)
+		}
+	}
+	return nil
+}
+
+// Validate проверяем данные для создания подписки
+func (w NewWebhook) Validate() error {
+	err := validateWebhookURL(w.URL)
+	if err != nil {
+		return err
+	}
+	return validateWebhookEvents(w.Events)
+}
+
+// Validate проверяем изменения подписки
+func (p WebhookPatch) Validate() error {
+	if p.URL != nil {
+		err := validateWebhookURL(*p.URL)
+		if err != nil {
+			return err
+		}
+	}
+	if p.Events != nil {
+		return validateWebhookEvents(*p.Events)
+	}
+	return nil
+}
+
+// webhookColumns колонки подписки без секрета
+const webhookColumns = "id, project_id, url, events, active, created_at"
+
+// scanWebhook читаем подписку из строки с колонками webhookColumns
+func scanWebhook(row interface{ Scan(...any) error }) (webhook Webhook, err error) {
+	err = row.Scan(&webhook.ID, &webhook.ProjectID, &webhook.URL, pq.Array(&webhook.Events), &webhook.Active, &webhook.CreatedAt)
+	if webhook.Events == nil {
+		webhook.Events = []string{}
+	}
+	return webhook, err
+}
+
+// FindWebhooks получаем подписки проекта
+func FindWebhooks(db *sql.DB, pID int) (payload json.RawMessage, err error) {
+	rows, err := db.Query("select "+webhookColumns+" from test_issue.webhooks where project_id = $1 order by id", pID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := WebhooksResponse{Webhooks: []Webhook{}}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		res.Webhooks = append(res.Webhooks, webhook)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// InsertWebhook добавляем подписку в проект, в ответе есть ключ подписи
+func InsertWebhook(db *sql.DB, pID int, newWebhook NewWebhook) (payload json.RawMessage, err error) {
+	secret := newWebhook.Secret
+	if secret == "" {
+		secret, err = newWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+	}
+	events := newWebhook.Events
+	if events == nil {
+		events = []string{}
+	}
+	active := newWebhook.Active == nil || *newWebhook.Active
+
+	row := db.QueryRow(`insert into test_issue.webhooks (project_id, url, secret, events, active)
+		select id, $2::text, $3::text, $4::text[], $5::bool from test_issue.projects where id = $1
+		returning `+webhookColumns, pID, newWebhook.URL, secret, pq.Array(events), active)
+	webhook, err := scanWebhook(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = secret
+
+	out, err := json.Marshal(webhook)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateWebhook меняем адрес, события или активность подписки
+func UpdateWebhook(db *sql.DB, ID, pID int, patch WebhookPatch) (payload json.RawMessage, err error) {
+	var events any
+	if patch.Events != nil {
+		events = pq.Array(*patch.Events)
+	}
+	row := db.QueryRow(`update test_issue.webhooks set
+		url = coalesce($3, url), events = coalesce($4, events), active = coalesce($5, active)
+		where id = $1 and project_id = $2
+		returning `+webhookColumns, ID, pID, patch.URL, events, patch.Active)
+	webhook, err := scanWebhook(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := json.Marshal(webhook)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteWebhook удаляем подписку вместе с журналом доставок
+func DeleteWebhook(db *sql.DB, ID, pID int) (payload json.RawMessage, err error) {
+	row := db.QueryRow("delete from test_issue.webhooks where id = $1 and project_id = $2 returning "+webhookColumns, ID, pID)
+	webhook, err := scanWebhook(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := json.Marshal(webhook)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// newWebhookSecret случайный ключ подписи
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// EnqueueDeliveries ставим события в очередь доставки всем активным подпискам их проектов
+func EnqueueDeliveries(db *sql.DB, msgs []natsLog.LogMessage) (queued int, err error) {
+	for _, msg := range msgs {
+		out, err := json.Marshal(msg)
+		if err != nil {
+			return queued, err
+		}
+		res, err := db.Exec(`insert into test_issue.webhook_deliveries (webhook_id, event, payload)
+			select id, $2::text, $3::jsonb from test_issue.webhooks
+			where project_id = $1 and active and (cardinality(events) = 0 or $2::text = any(events))`,
+			msg.ProjectID, msg.Event, string(out))
+		if err != nil {
+			return queued, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return queued, err
+		}
+		queued += int(n)
+	}
+	return queued, nil
+}
+
+// deliveryColumns колонки доставки
+const deliveryColumns = "id, webhook_id, event, payload, status, attempts, response_status, last_error, next_attempt_at, created_at, delivered_at"
+
+// scanDelivery читаем доставку из строки с колонками deliveryColumns
+func scanDelivery(row interface{ Scan(...any) error }) (delivery Delivery, err error) {
+	var payload []byte
+	err = row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.Event, &payload, &delivery.Status, &delivery.Attempts,
+		&delivery.ResponseStatus, &delivery.LastError, &delivery.NextAttemptAt, &delivery.CreatedAt, &delivery.DeliveredAt)
+	delivery.Payload = payload
+	return delivery, err
+}
+
+// ClaimDeliveries забираем до limit доставок, которым пора уходить. Попытка засчитывается сразу,
+// а следующая откладывается на lease: если экземпляр упадет во время отправки, доставку подхватит другой.
+// skip locked не дает двум экземплярам забрать одну доставку. Доставки выключенных подписок тоже забираются,
+// но без попытки и с Active = false, чтобы отправитель закрыл их как skipped, а не отправлял
+func ClaimDeliveries(db *sql.DB, limit int, lease time.Duration) (deliveries []Delivery, err error) {
+	rows, err := db.Query(`update test_issue.webhook_deliveries d
+		set attempts = d.attempts + case when w.active then 1 else 0 end,
+			next_attempt_at = now() + $2::float8 * interval '1 millisecond'
+		from test_issue.webhooks w
+		where w.id = d.webhook_id and d.id in (
+			select id from test_issue.webhook_deliveries
+			where status = 'pending' and next_attempt_at <= now()
+			order by next_attempt_at, id limit $1 for update skip locked)
+		returning d.id, d.webhook_id, d.event, d.payload, d.status, d.attempts, d.response_status, d.last_error,
+			d.next_attempt_at, d.created_at, d.delivered_at, w.url, w.secret, w.active`, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		delivery := Delivery{}
+		var payload []byte
+		err = rows.Scan(&delivery.ID, &delivery.WebhookID, &delivery.Event, &payload, &delivery.Status, &delivery.Attempts,
+			&delivery.ResponseStatus, &delivery.LastError, &delivery.NextAttemptAt, &delivery.CreatedAt, &delivery.DeliveredAt,
+			&delivery.URL, &delivery.Secret, &delivery.Active)
+		if err != nil {
+			return nil, err
+		}
+		delivery.Payload = payload
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// SaveDeliveryResult записываем в журнал итог попытки доставки
+func SaveDeliveryResult(db *sql.DB, ID int64, res DeliveryResult) error {
+	var responseStatus, lastError any
+	if res.ResponseStatus != 0 {
+		responseStatus = res.ResponseStatus
+	}
+	if res.Error != "" {
+		lastError = res.Error
+	}
+	_, err := db.Exec(`update test_issue.webhook_deliveries set status = $2, response_status = $3, last_error = $4,
+		next_attempt_at = case when $2 = 'pending' then now() + $5::float8 * interval '1 millisecond' end,
+		delivered_at = case when $2 = 'delivered' then now() end
+		where id = $1`, ID, res.Status, responseStatus, lastError, res.RetryIn.Milliseconds())
+	return err
+}
+
+// FindDeliveries журнал доставок подписки, новые сначала
+func FindDeliveries(db *sql.DB, webhookID, pID, limit int) (payload json.RawMessage, err error) {
+	exists := false
+	err = db.QueryRow("select exists(select 1 from test_issue.webhooks where id = $1 and project_id = $2)", webhookID, pID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrWebhookNotFound
+	}
+
+	rows, err := db.Query("select "+deliveryColumns+" from test_issue.webhook_deliveries where webhook_id = $1 order by id desc limit $2", webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := DeliveriesResponse{Deliveries: []Delivery{}}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		res.Deliveries = append(res.Deliveries, delivery)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReplayDelivery ставим доставку в очередь заново с полным числом попыток, в том числе уже доставленную.
+// Доставку выключенной подписки повторить нельзя
+func ReplayDelivery(db *sql.DB, ID int64, webhookID, pID int) (payload json.RawMessage, err error) {
+	row := db.QueryRow(`update test_issue.webhook_deliveries d
+		set status = 'pending', attempts = 0, next_attempt_at = now(), delivered_at = null
+		from test_issue.webhooks w
+		where d.id = $1 and d.webhook_id = $2 and w.id = d.webhook_id and w.project_id = $3 and w.active
+		returning d.id, d.webhook_id, d.event, d.payload, d.status, d.attempts, d.response_status, d.last_error,
+			d.next_attempt_at, d.created_at, d.delivered_at`, ID, webhookID, pID)
+	delivery, err := scanDelivery(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, replayMissing(db, ID, webhookID, pID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := json.Marshal(delivery)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// replayMissing почему доставку не удалось повторить: ее нет или подписка выключена
+func replayMissing(db *sql.DB, ID int64, webhookID, pID int) error {
+	active := false
+	err := db.QueryRow(`select w.active from test_issue.webhook_deliveries d
+		join test_issue.webhooks w on w.id = d.webhook_id
+		where d.id = $1 and d.webhook_id = $2 and w.project_id = $3`, ID, webhookID, pID).Scan(&active)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err == nil && !active {
+		return ErrWebhookInactive
+	}
+	return ErrDeliveryNotFound
+}
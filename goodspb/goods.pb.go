@@ -0,0 +1,1077 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: goodspb/goods.proto
+
+// Сервис товаров, те же операции, что и в REST API
+
+package goodspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Position начало или конец проекта
+type Position int32
+
+const (
+	Position_POSITION_UNSPECIFIED Position = 0
+	Position_POSITION_TOP         Position = 1
+	Position_POSITION_BOTTOM      Position = 2
+)
+
+// Enum value maps for Position.
+var (
+	Position_name = map[int32]string{
+		0: "POSITION_UNSPECIFIED",
+		1: "POSITION_TOP",
+		2: "POSITION_BOTTOM",
+	}
+	Position_value = map[string]int32{
+		"POSITION_UNSPECIFIED": 0,
+		"POSITION_TOP":         1,
+		"POSITION_BOTTOM":      2,
+	}
+)
+
+func (x Position) Enum() *Position {
+	p := new(Position)
+	*p = x
+	return p
+}
+
+func (x Position) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Position) Descriptor() protoreflect.EnumDescriptor {
+	return file_goodspb_goods_proto_enumTypes[0].Descriptor()
+}
+
+func (Position) Type() protoreflect.EnumType {
+	return &file_goodspb_goods_proto_enumTypes[0]
+}
+
+func (x Position) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Position.Descriptor instead.
+func (Position) EnumDescriptor() ([]byte, []int) {
+	return file_goodspb_goods_proto_rawDescGZIP(), []int{0}
+}
+
+// Good товар
+type Good struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ProjectId   int32                  `protobuf:"varint,2,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	Name        string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Description *string                `protobuf:"bytes,4,opt,name=description,proto3,oneof" json:"description,omitempty"`
+	// позиция товара в проекте, начиная с 1
+	Priority      int32                  `protobuf:"varint,5,opt,name=priority,proto3" json:"priority,omitempty"`
+	Removed       bool                   `protobuf:"varint,6,opt,name=removed,proto3" json:"removed,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Version       int32                  `protobuf:"varint,8,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Good) Reset() {
+	*x = Good{}
+	mi := &file_goodspb_goods_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Good) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Good) ProtoMessage() {}
+
+func (x *Good) ProtoReflect() protoreflect.Message {
+	mi := &file_goodspb_goods_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Good.ProtoReflect.Descriptor instead.
+func (*Good) Descriptor() ([]byte, []int) {
+	return file_goodspb_goods_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Good) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Good) GetProjectId() int32 {
+	if x != nil {
+		return x.ProjectId
+	}
+	return 0
+}
+
+func (x *Good) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Good) GetDescription() string {
+	if x != nil && x.Description != nil {
+		return *x.Description
+	}
+	return ""
+}
+
+func (x *Good) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *Good) GetRemoved() bool {
+	if x != nil {
+		return x.Removed
+	}
+	return false
+}
+
+func (x *Good) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Good) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// Meta метаданные списка
+type Meta struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         int32                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Removed       int32                  `protobuf:"varint,2,opt,name=removed,proto3" json:"removed,omitempty"`
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	Cursor        string                 `protobuf:"bytes,5,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,6,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Meta) Reset() {
+	*x = Meta{}
+	mi := &file_goodspb_goods_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Meta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Meta) ProtoMessage() {}
+
+func (x *Meta) ProtoReflect() protoreflect.Message {
+	mi := &file_goodspb_goods_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Meta.ProtoReflect.Descriptor instead.
+func (*Meta) Descriptor() ([]byte, []int) {
+	return file_goodspb_goods_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Meta) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *Meta) GetRemoved() int32 {
+	if x != nil {
+		return x.Removed
+	}
+	return 0
+}
+
+func (x *Meta) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *Meta) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *Meta) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *Meta) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+// ListGoodsRequest фильтр и страница списка, как параметры GET /v1/goods
+type ListGoodsRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId   int32                  `protobuf:"varint,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	Removed     *bool                  `protobuf:"varint,2,opt,name=removed,proto3,oneof" json:"removed,omitempty"`
+	Search      string                 `protobuf:"bytes,3,opt,name=search,proto3" json:"search,omitempty"`
+	CreatedFrom *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_from,json=createdFrom,proto3" json:"created_from,omitempty"`
+	CreatedTo   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_to,json=createdTo,proto3" json:"created_to,omitempty"`
+	// id, priority или createdAt, с минусом - по убыванию
+	Sort string `protobuf:"bytes,6,opt,name=sort,proto3" json:"sort,omitempty"`
+	// по умолчанию 10
+	Limit int32 `protobuf:"varint,7,opt,name=limit,proto3" json:"limit,omitempty"`
+	// сколько товаров пропустить, по умолчанию 0
+	Offset int32 `protobuf:"varint,8,opt,name=offset,proto3" json:"offset,omitempty"`
+	// наличие курсора включает выдачу по курсору, пустой - первая страница
+	Cursor        *string `protobuf:"bytes,9,opt,name=cursor,proto3,oneof" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListGoodsRequest) Reset() {
+	*x = ListGoodsRequest{}
+	mi := &file_goodspb_goods_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGoodsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGoodsRequest) ProtoMessage() {}
+
+func (x *ListGoodsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goodspb_goods_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGoodsRequest.ProtoReflect.Descriptor instead.
+func (*ListGoodsRequest) Descriptor() ([]byte, []int) {
+	return file_goodspb_goods_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListGoodsRequest) GetProjectId() int32 {
+	if x != nil {
+		return x.ProjectId
+	}
+	return 0
+}
+
+func (x *ListGoodsRequest) GetRemoved() bool {
+	if x != nil && x.Removed != nil {
+		return *x.Removed
+	}
+	return false
+}
+
+func (x *ListGoodsRequest) GetSearch() string {
+	if x != nil {
+		return x.Search
+	}
+	return ""
+}
+
+func (x *ListGoodsRequest) GetCreatedFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedFrom
+	}
+	return nil
+}
+
+func (x *ListGoodsRequest) GetCreatedTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedTo
+	}
+	return nil
+}
+
+func (x *ListGoodsRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListGoodsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListGoodsRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListGoodsRequest) GetCursor() string {
+	if x != nil && x.Cursor != nil {
+		return *x.Cursor
+	}
+	return ""
+}
+
+type ListGoodsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Meta          *Meta                  `protobuf:"bytes,1,opt,name=meta,proto3" json:"meta,omitempty"`
+	Goods         []*Good                `protobuf:"bytes,2,rep,name=goods,proto3" json:"goods,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListGoodsResponse) Reset() {
+	*x = ListGoodsResponse{}
+	mi := &file_goodspb_goods_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGoodsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGoodsResponse) ProtoMessage() {}
+
+func (x *ListGoodsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_goodspb_goods_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGoodsResponse.ProtoReflect.Descriptor instead.
+func (*ListGoodsResponse) Descriptor() ([]byte, []int) {
+	return file_goodspb_goods_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListGoodsResponse) GetMeta() *Meta {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+func (x *ListGoodsResponse) GetGoods() []*Good {
+	if x != nil {
+		return x.Goods
+	}
+	return nil
+}
+
+type GetGoodRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId     int32                  `protobuf:"varint,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	Id            int32                  `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetGoodRequest) Reset() {
+	*x = GetGoodRequest{}
+	mi := &file_goodspb_goods_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGoodRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGoodRequest) ProtoMessage() {}
+
+func (x *GetGoodRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goodspb_goods_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGoodRequest.ProtoReflect.Descriptor instead.
+func (*GetGoodRequest) Descriptor() ([]byte, []int) {
+	return file_goodspb_goods_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetGoodRequest) GetProjectId() int32 {
+	if x != nil {
+		return x.ProjectId
+	}
+	return 0
+}
+
+func (x *GetGoodRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+// CreateGoodRequest без priority и position товар встает в конец проекта
+type CreateGoodRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId     int32                  `protobuf:"varint,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description   *string                `protobuf:"bytes,3,opt,name=description,proto3,oneof" json:"description,omitempty"`
+	Priority      int32                  `protobuf:"varint,4,opt,name=priority,proto3" json:"priority,omitempty"`
+	Position      Position               `protobuf:"varint,5,opt,name=position,proto3,enum=goods.v1.Position" json:"position,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateGoodRequest) Reset() {
+	*x = CreateGoodRequest{}
+	mi := &file_goodspb_goods_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateGoodRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateGoodRequest) ProtoMessage() {}
+
+func (x *CreateGoodRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goodspb_goods_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateGoodRequest.ProtoReflect.Descriptor instead.
+func (*CreateGoodRequest) Descriptor() ([]byte, []int) {
+	return file_goodspb_goods_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CreateGoodRequest) GetProjectId() int32 {
+	if x != nil {
+		return x.ProjectId
+	}
+	return 0
+}
+
+func (x *CreateGoodRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateGoodRequest) GetDescription() string {
+	if x != nil && x.Description != nil {
+		return *x.Description
+	}
+	return ""
+}
+
+func (x *CreateGoodRequest) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *CreateGoodRequest) GetPosition() Position {
+	if x != nil {
+		return x.Position
+	}
+	return Position_POSITION_UNSPECIFIED
+}
+
+// UpdateGoodRequest меняются только переданные поля, clear_description убирает описание.
+// version - ожидаемая версия товара, 0 - любая
+type UpdateGoodRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId        int32                  `protobuf:"varint,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	Id               int32                  `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+	Version          int32                  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	Name             *string                `protobuf:"bytes,4,opt,name=name,proto3,oneof" json:"name,omitempty"`
+	Description      *string                `protobuf:"bytes,5,opt,name=description,proto3,oneof" json:"description,omitempty"`
+	ClearDescription bool                   `protobuf:"varint,6,opt,name=clear_description,json=clearDescription,proto3" json:"clear_description,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *UpdateGoodRequest) Reset() {
+	*x = UpdateGoodRequest{}
+	mi := &file_goodspb_goods_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateGoodRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateGoodRequest) ProtoMessage() {}
+
+func (x *UpdateGoodRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goodspb_goods_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateGoodRequest.ProtoReflect.Descriptor instead.
+func (*UpdateGoodRequest) Descriptor() ([]byte, []int) {
+	return file_goodspb_goods_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UpdateGoodRequest) GetProjectId() int32 {
+	if x != nil {
+		return x.ProjectId
+	}
+	return 0
+}
+
+func (x *UpdateGoodRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateGoodRequest) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *UpdateGoodRequest) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *UpdateGoodRequest) GetDescription() string {
+	if x != nil && x.Description != nil {
+		return *x.Description
+	}
+	return ""
+}
+
+func (x *UpdateGoodRequest) GetClearDescription() bool {
+	if x != nil {
+		return x.ClearDescription
+	}
+	return false
+}
+
+// RemoveGoodRequest version - ожидаемая версия товара, 0 - любая
+type RemoveGoodRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId     int32                  `protobuf:"varint,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	Id            int32                  `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+	Version       int32                  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveGoodRequest) Reset() {
+	*x = RemoveGoodRequest{}
+	mi := &file_goodspb_goods_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveGoodRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveGoodRequest) ProtoMessage() {}
+
+func (x *RemoveGoodRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goodspb_goods_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveGoodRequest.ProtoReflect.Descriptor instead.
+func (*RemoveGoodRequest) Descriptor() ([]byte, []int) {
+	return file_goodspb_goods_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RemoveGoodRequest) GetProjectId() int32 {
+	if x != nil {
+		return x.ProjectId
+	}
+	return 0
+}
+
+func (x *RemoveGoodRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *RemoveGoodRequest) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// ReprioritizeGoodRequest нужно передать ровно одно из priority, before, after или position.
+// version - ожидаемая версия товара, 0 - любая
+type ReprioritizeGoodRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId int32                  `protobuf:"varint,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	Id        int32                  `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+	Version   int32                  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	// Types that are valid to be assigned to Target:
+	//
+	//	*ReprioritizeGoodRequest_Priority
+	//	*ReprioritizeGoodRequest_Before
+	//	*ReprioritizeGoodRequest_After
+	//	*ReprioritizeGoodRequest_Position
+	Target        isReprioritizeGoodRequest_Target `protobuf_oneof:"target"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReprioritizeGoodRequest) Reset() {
+	*x = ReprioritizeGoodRequest{}
+	mi := &file_goodspb_goods_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReprioritizeGoodRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReprioritizeGoodRequest) ProtoMessage() {}
+
+func (x *ReprioritizeGoodRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goodspb_goods_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReprioritizeGoodRequest.ProtoReflect.Descriptor instead.
+func (*ReprioritizeGoodRequest) Descriptor() ([]byte, []int) {
+	return file_goodspb_goods_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReprioritizeGoodRequest) GetProjectId() int32 {
+	if x != nil {
+		return x.ProjectId
+	}
+	return 0
+}
+
+func (x *ReprioritizeGoodRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ReprioritizeGoodRequest) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ReprioritizeGoodRequest) GetTarget() isReprioritizeGoodRequest_Target {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+func (x *ReprioritizeGoodRequest) GetPriority() int32 {
+	if x != nil {
+		if x, ok := x.Target.(*ReprioritizeGoodRequest_Priority); ok {
+			return x.Priority
+		}
+	}
+	return 0
+}
+
+func (x *ReprioritizeGoodRequest) GetBefore() int32 {
+	if x != nil {
+		if x, ok := x.Target.(*ReprioritizeGoodRequest_Before); ok {
+			return x.Before
+		}
+	}
+	return 0
+}
+
+func (x *ReprioritizeGoodRequest) GetAfter() int32 {
+	if x != nil {
+		if x, ok := x.Target.(*ReprioritizeGoodRequest_After); ok {
+			return x.After
+		}
+	}
+	return 0
+}
+
+func (x *ReprioritizeGoodRequest) GetPosition() Position {
+	if x != nil {
+		if x, ok := x.Target.(*ReprioritizeGoodRequest_Position); ok {
+			return x.Position
+		}
+	}
+	return Position_POSITION_UNSPECIFIED
+}
+
+type isReprioritizeGoodRequest_Target interface {
+	isReprioritizeGoodRequest_Target()
+}
+
+type ReprioritizeGoodRequest_Priority struct {
+	Priority int32 `protobuf:"varint,4,opt,name=priority,proto3,oneof"`
+}
+
+type ReprioritizeGoodRequest_Before struct {
+	Before int32 `protobuf:"varint,5,opt,name=before,proto3,oneof"`
+}
+
+type ReprioritizeGoodRequest_After struct {
+	After int32 `protobuf:"varint,6,opt,name=after,proto3,oneof"`
+}
+
+type ReprioritizeGoodRequest_Position struct {
+	Position Position `protobuf:"varint,7,opt,name=position,proto3,enum=goods.v1.Position,oneof"`
+}
+
+func (*ReprioritizeGoodRequest_Priority) isReprioritizeGoodRequest_Target() {}
+
+func (*ReprioritizeGoodRequest_Before) isReprioritizeGoodRequest_Target() {}
+
+func (*ReprioritizeGoodRequest_After) isReprioritizeGoodRequest_Target() {}
+
+func (*ReprioritizeGoodRequest_Position) isReprioritizeGoodRequest_Target() {}
+
+type ReprioritizeGoodResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Priorities    []*Good                `protobuf:"bytes,1,rep,name=priorities,proto3" json:"priorities,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReprioritizeGoodResponse) Reset() {
+	*x = ReprioritizeGoodResponse{}
+	mi := &file_goodspb_goods_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReprioritizeGoodResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReprioritizeGoodResponse) ProtoMessage() {}
+
+func (x *ReprioritizeGoodResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_goodspb_goods_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReprioritizeGoodResponse.ProtoReflect.Descriptor instead.
+func (*ReprioritizeGoodResponse) Descriptor() ([]byte, []int) {
+	return file_goodspb_goods_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ReprioritizeGoodResponse) GetPriorities() []*Good {
+	if x != nil {
+		return x.Priorities
+	}
+	return nil
+}
+
+var File_goodspb_goods_proto protoreflect.FileDescriptor
+
+const file_goodspb_goods_proto_rawDesc = "" +
+	"\n" +
+	"\x13goodspb/goods.proto\x12\bgoods.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x8b\x02\n" +
+	"\x04Good\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x02 \x01(\x05R\tprojectId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12%\n" +
+	"\vdescription\x18\x04 \x01(\tH\x00R\vdescription\x88\x01\x01\x12\x1a\n" +
+	"\bpriority\x18\x05 \x01(\x05R\bpriority\x12\x18\n" +
+	"\aremoved\x18\x06 \x01(\bR\aremoved\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x18\n" +
+	"\aversion\x18\b \x01(\x05R\aversionB\x0e\n" +
+	"\f_description\"\x9d\x01\n" +
+	"\x04Meta\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x05R\x05total\x12\x18\n" +
+	"\aremoved\x18\x02 \x01(\x05R\aremoved\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x05R\x06offset\x12\x16\n" +
+	"\x06cursor\x18\x05 \x01(\tR\x06cursor\x12\x1f\n" +
+	"\vnext_cursor\x18\x06 \x01(\tR\n" +
+	"nextCursor\"\xd8\x02\n" +
+	"\x10ListGoodsRequest\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\x05R\tprojectId\x12\x1d\n" +
+	"\aremoved\x18\x02 \x01(\bH\x00R\aremoved\x88\x01\x01\x12\x16\n" +
+	"\x06search\x18\x03 \x01(\tR\x06search\x12=\n" +
+	"\fcreated_from\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vcreatedFrom\x129\n" +
+	"\n" +
+	"created_to\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedTo\x12\x12\n" +
+	"\x04sort\x18\x06 \x01(\tR\x04sort\x12\x14\n" +
+	"\x05limit\x18\a \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\b \x01(\x05R\x06offset\x12\x1b\n" +
+	"\x06cursor\x18\t \x01(\tH\x01R\x06cursor\x88\x01\x01B\n" +
+	"\n" +
+	"\b_removedB\t\n" +
+	"\a_cursor\"]\n" +
+	"\x11ListGoodsResponse\x12\"\n" +
+	"\x04meta\x18\x01 \x01(\v2\x0e.goods.v1.MetaR\x04meta\x12$\n" +
+	"\x05goods\x18\x02 \x03(\v2\x0e.goods.v1.GoodR\x05goods\"?\n" +
+	"\x0eGetGoodRequest\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\x05R\tprojectId\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\x05R\x02id\"\xc9\x01\n" +
+	"\x11CreateGoodRequest\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\x05R\tprojectId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12%\n" +
+	"\vdescription\x18\x03 \x01(\tH\x00R\vdescription\x88\x01\x01\x12\x1a\n" +
+	"\bpriority\x18\x04 \x01(\x05R\bpriority\x12.\n" +
+	"\bposition\x18\x05 \x01(\x0e2\x12.goods.v1.PositionR\bpositionB\x0e\n" +
+	"\f_description\"\xe2\x01\n" +
+	"\x11UpdateGoodRequest\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\x05R\tprojectId\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\x05R\x02id\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x05R\aversion\x12\x17\n" +
+	"\x04name\x18\x04 \x01(\tH\x00R\x04name\x88\x01\x01\x12%\n" +
+	"\vdescription\x18\x05 \x01(\tH\x01R\vdescription\x88\x01\x01\x12+\n" +
+	"\x11clear_description\x18\x06 \x01(\bR\x10clearDescriptionB\a\n" +
+	"\x05_nameB\x0e\n" +
+	"\f_description\"\\\n" +
+	"\x11RemoveGoodRequest\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\x05R\tprojectId\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\x05R\x02id\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x05R\aversion\"\xee\x01\n" +
+	"\x17ReprioritizeGoodRequest\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\x05R\tprojectId\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\x05R\x02id\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x05R\aversion\x12\x1c\n" +
+	"\bpriority\x18\x04 \x01(\x05H\x00R\bpriority\x12\x18\n" +
+	"\x06before\x18\x05 \x01(\x05H\x00R\x06before\x12\x16\n" +
+	"\x05after\x18\x06 \x01(\x05H\x00R\x05after\x120\n" +
+	"\bposition\x18\a \x01(\x0e2\x12.goods.v1.PositionH\x00R\bpositionB\b\n" +
+	"\x06target\"J\n" +
+	"\x18ReprioritizeGoodResponse\x12.\n" +
+	"\n" +
+	"priorities\x18\x01 \x03(\v2\x0e.goods.v1.GoodR\n" +
+	"priorities*K\n" +
+	"\bPosition\x12\x18\n" +
+	"\x14POSITION_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fPOSITION_TOP\x10\x01\x12\x13\n" +
+	"\x0fPOSITION_BOTTOM\x10\x022\x95\x03\n" +
+	"\fGoodsService\x12D\n" +
+	"\tListGoods\x12\x1a.goods.v1.ListGoodsRequest\x1a\x1b.goods.v1.ListGoodsResponse\x123\n" +
+	"\aGetGood\x12\x18.goods.v1.GetGoodRequest\x1a\x0e.goods.v1.Good\x129\n" +
+	"\n" +
+	"CreateGood\x12\x1b.goods.v1.CreateGoodRequest\x1a\x0e.goods.v1.Good\x129\n" +
+	"\n" +
+	"UpdateGood\x12\x1b.goods.v1.UpdateGoodRequest\x1a\x0e.goods.v1.Good\x129\n" +
+	"\n" +
+	"RemoveGood\x12\x1b.goods.v1.RemoveGoodRequest\x1a\x0e.goods.v1.Good\x12Y\n" +
+	"\x10ReprioritizeGood\x12!.goods.v1.ReprioritizeGoodRequest\x1a\".goods.v1.ReprioritizeGoodResponseB\x0eZ\fmain/goodspbb\x06proto3"
+
+var (
+	file_goodspb_goods_proto_rawDescOnce sync.Once
+	file_goodspb_goods_proto_rawDescData []byte
+)
+
+func file_goodspb_goods_proto_rawDescGZIP() []byte {
+	file_goodspb_goods_proto_rawDescOnce.Do(func() {
+		file_goodspb_goods_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_goodspb_goods_proto_rawDesc), len(file_goodspb_goods_proto_rawDesc)))
+	})
+	return file_goodspb_goods_proto_rawDescData
+}
+
+var file_goodspb_goods_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_goodspb_goods_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_goodspb_goods_proto_goTypes = []any{
+	(Position)(0),                    // 0: goods.v1.Position
+	(*Good)(nil),                     // 1: goods.v1.Good
+	(*Meta)(nil),                     // 2: goods.v1.Meta
+	(*ListGoodsRequest)(nil),         // 3: goods.v1.ListGoodsRequest
+	(*ListGoodsResponse)(nil),        // 4: goods.v1.ListGoodsResponse
+	(*GetGoodRequest)(nil),           // 5: goods.v1.GetGoodRequest
+	(*CreateGoodRequest)(nil),        // 6: goods.v1.CreateGoodRequest
+	(*UpdateGoodRequest)(nil),        // 7: goods.v1.UpdateGoodRequest
+	(*RemoveGoodRequest)(nil),        // 8: goods.v1.RemoveGoodRequest
+	(*ReprioritizeGoodRequest)(nil),  // 9: goods.v1.ReprioritizeGoodRequest
+	(*ReprioritizeGoodResponse)(nil), // 10: goods.v1.ReprioritizeGoodResponse
+	(*timestamppb.Timestamp)(nil),    // 11: google.protobuf.Timestamp
+}
+var file_goodspb_goods_proto_depIdxs = []int32{
+	11, // 0: goods.v1.Good.created_at:type_name -> google.protobuf.Timestamp
+	11, // 1: goods.v1.ListGoodsRequest.created_from:type_name -> google.protobuf.Timestamp
+	11, // 2: goods.v1.ListGoodsRequest.created_to:type_name -> google.protobuf.Timestamp
+	2,  // 3: goods.v1.ListGoodsResponse.meta:type_name -> goods.v1.Meta
+	1,  // 4: goods.v1.ListGoodsResponse.goods:type_name -> goods.v1.Good
+	0,  // 5: goods.v1.CreateGoodRequest.position:type_name -> goods.v1.Position
+	0,  // 6: goods.v1.ReprioritizeGoodRequest.position:type_name -> goods.v1.Position
+	1,  // 7: goods.v1.ReprioritizeGoodResponse.priorities:type_name -> goods.v1.Good
+	3,  // 8: goods.v1.GoodsService.ListGoods:input_type -> goods.v1.ListGoodsRequest
+	5,  // 9: goods.v1.GoodsService.GetGood:input_type -> goods.v1.GetGoodRequest
+	6,  // 10: goods.v1.GoodsService.CreateGood:input_type -> goods.v1.CreateGoodRequest
+	7,  // 11: goods.v1.GoodsService.UpdateGood:input_type -> goods.v1.UpdateGoodRequest
+	8,  // 12: goods.v1.GoodsService.RemoveGood:input_type -> goods.v1.RemoveGoodRequest
+	9,  // 13: goods.v1.GoodsService.ReprioritizeGood:input_type -> goods.v1.ReprioritizeGoodRequest
+	4,  // 14: goods.v1.GoodsService.ListGoods:output_type -> goods.v1.ListGoodsResponse
+	1,  // 15: goods.v1.GoodsService.GetGood:output_type -> goods.v1.Good
+	1,  // 16: goods.v1.GoodsService.CreateGood:output_type -> goods.v1.Good
+	1,  // 17: goods.v1.GoodsService.UpdateGood:output_type -> goods.v1.Good
+	1,  // 18: goods.v1.GoodsService.RemoveGood:output_type -> goods.v1.Good
+	10, // 19: goods.v1.GoodsService.ReprioritizeGood:output_type -> goods.v1.ReprioritizeGoodResponse
+	14, // [14:20] is the sub-list for method output_type
+	8,  // [8:14] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_goodspb_goods_proto_init() }
+func file_goodspb_goods_proto_init() {
+	if File_goodspb_goods_proto != nil {
+		return
+	}
+	file_goodspb_goods_proto_msgTypes[0].OneofWrappers = []any{}
+	file_goodspb_goods_proto_msgTypes[2].OneofWrappers = []any{}
+	file_goodspb_goods_proto_msgTypes[5].OneofWrappers = []any{}
+	file_goodspb_goods_proto_msgTypes[6].OneofWrappers = []any{}
+	file_goodspb_goods_proto_msgTypes[8].OneofWrappers = []any{
+		(*ReprioritizeGoodRequest_Priority)(nil),
+		(*ReprioritizeGoodRequest_Before)(nil),
+		(*ReprioritizeGoodRequest_After)(nil),
+		(*ReprioritizeGoodRequest_Position)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_goodspb_goods_proto_rawDesc), len(file_goodspb_goods_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_goodspb_goods_proto_goTypes,
+		DependencyIndexes: file_goodspb_goods_proto_depIdxs,
+		EnumInfos:         file_goodspb_goods_proto_enumTypes,
+		MessageInfos:      file_goodspb_goods_proto_msgTypes,
+	}.Build()
+	File_goodspb_goods_proto = out.File
+	file_goodspb_goods_proto_goTypes = nil
+	file_goodspb_goods_proto_depIdxs = nil
+}
@@ -0,0 +1,137 @@
+package grpcapi
+
+import (
+	"context"
+	"log"
+	"main/database"
+	"main/goodspb"
+	"main/handler"
+	"main/i18n"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// listParams фильтр и страница из запроса: без limit отдается 10 товаров, offset передается как есть, 0 - с первого товара
+func listParams(req *goodspb.ListGoodsRequest) (filter database.GoodsFilter, page database.Page, err error) {
+	filter = database.GoodsFilter{
+		ProjectID: int(req.GetProjectId()),
+		Removed:   req.Removed,
+		Search:    req.GetSearch(),
+		Sort:      req.GetSort(),
+	}
+	if req.CreatedFrom != nil {
+		t := req.CreatedFrom.AsTime()
+		filter.CreatedFrom = &t
+	}
+	if req.CreatedTo != nil {
+		t := req.CreatedTo.AsTime()
+		filter.CreatedTo = &t
+	}
+	err = filter.Validate()
+	if err != nil {
+		return filter, page, err
+	}
+
+	page = database.Page{Limit: int(req.GetLimit()), Offset: int(req.GetOffset())}
+	if page.Limit == 0 {
+		page.Limit = 10
+	}
+	if req.Cursor != nil {
+		page.Keyset = true
+		page.Cursor = req.GetCursor()
+		page.Offset = 0
+		if page.Limit <= 0 {
+			return filter, page, database.ErrLimitNotPositive
+		}
+	}
+	return filter, page, nil
+}
+
+// toListResponse список товаров в формате protobuf
+func toListResponse(res database.GoodsResponse) *goodspb.ListGoodsResponse {
+	out := &goodspb.ListGoodsResponse{
+		Meta: &goodspb.Meta{
+			Total:      int32(res.Meta.Total),
+			Removed:    int32(res.Meta.Removed),
+			Limit:      int32(res.Meta.Limit),
+			Offset:     int32(res.Meta.Offset),
+			Cursor:     res.Meta.Cursor,
+			NextCursor: res.Meta.NextCursor,
+		},
+	}
+	for _, good := range res.Goods {
+		out.Goods = append(out.Goods, toGood(good))
+	}
+	return out
+}
+
+// toGood товар в формате protobuf
+func toGood(good database.Good) *goodspb.Good {
+	out := &goodspb.Good{
+		Id:          int32(good.ID),
+		ProjectId:   int32(good.ProjectID),
+		Name:        good.Name,
+		Description: good.Description,
+		Priority:    int32(good.Priority),
+		Removed:     good.Removed,
+		Version:     int32(good.Version),
+	}
+	if good.CreatedAt != nil {
+		out.CreatedAt = timestamppb.New(*good.CreatedAt)
+	}
+	return out
+}
+
+// fromPosition позиция из protobuf в виде database.PositionTop или database.PositionBottom
+func fromPosition(p goodspb.Position) string {
+	switch p {
+	case goodspb.Position_POSITION_TOP:
+		return database.PositionTop
+	case goodspb.Position_POSITION_BOTTOM:
+		return database.PositionBottom
+	}
+	return ""
+}
+
+// toStatus ошибка в виде статуса gRPC. Код выбирается по http-статусу той же ошибки в REST,
+// сообщение на языке из метаданных accept-language
+func toStatus(ctx context.Context, err error) error {
+	e := handler.ToError(err)
+	if e.Status >= http.StatusInternalServerError {
+		log.Print(err)
+	}
+	message := e.Message
+	if i18n.Has(e.Key) {
+		message = i18n.Message(requestLang(ctx), e.Key)
+	}
+	return status.Error(grpcCode(e.Status), message)
+}
+
+// grpcCode код gRPC для http-статуса
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	}
+	return codes.Internal
+}
+
+// requestLang язык ответа по метаданным accept-language
+func requestLang(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("accept-language")
+	if len(values) == 0 {
+		return i18n.DefaultLang
+	}
+	return i18n.Lang(values[0])
+}
@@ -0,0 +1,150 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"main/database"
+	"main/goodspb"
+	"main/handler"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// GrpcConfig конфигурация для gRPC-сервера
+type GrpcConfig struct {
+	Port string `yaml:"port"`
+}
+
+// Server сервис товаров поверх тех же операций, что и у REST-обработчика
+type Server struct {
+	goodspb.UnimplementedGoodsServiceServer
+	rh handler.RestHandler
+}
+
+// NewServer получаем сервис товаров
+func NewServer(rh handler.RestHandler) *Server {
+	return &Server{rh: rh}
+}
+
+// Serve запускаем gRPC-сервер с сервисом товаров, reflection и health
+func Serve(cfg GrpcConfig, rh handler.RestHandler) error {
+	lis, err := net.Listen("tcp", ":"+cfg.Port)
+	if err != nil {
+		return err
+	}
+	s := grpc.NewServer()
+	goodspb.RegisterGoodsServiceServer(s, NewServer(rh))
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	reflection.Register(s)
+	log.Printf("grpc listening on %s", lis.Addr())
+	return s.Serve(lis)
+}
+
+// ListGoods список товаров с фильтром, как GET /v1/goods
+func (s *Server) ListGoods(ctx context.Context, req *goodspb.ListGoodsRequest) (*goodspb.ListGoodsResponse, error) {
+	filter, page, err := listParams(req)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	cached, err := s.rh.ListGoods(filter, page)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	res := database.GoodsResponse{}
+	err = json.Unmarshal(cached.Payload, &res)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toListResponse(res), nil
+}
+
+// GetGood товар по id и проекту
+func (s *Server) GetGood(ctx context.Context, req *goodspb.GetGoodRequest) (*goodspb.Good, error) {
+	payload, err := s.rh.FindGood(int(req.GetId()), int(req.GetProjectId()))
+	return goodReply(ctx, payload, err)
+}
+
+// CreateGood добавляем товар в проект
+func (s *Server) CreateGood(ctx context.Context, req *goodspb.CreateGoodRequest) (*goodspb.Good, error) {
+	good := database.NewGood{
+		Name:        req.GetName(),
+		Description: req.Description,
+		Priority:    int(req.GetPriority()),
+		Position:    fromPosition(req.GetPosition()),
+	}
+	payload, err := s.rh.CreateGood(int(req.GetProjectId()), good)
+	return goodReply(ctx, payload, err)
+}
+
+// UpdateGood меняем имя и описание товара
+func (s *Server) UpdateGood(ctx context.Context, req *goodspb.UpdateGoodRequest) (*goodspb.Good, error) {
+	patch := database.GoodPatch{}
+	if req.Name != nil {
+		patch.Name = database.OptionalString{Set: true, Value: req.GetName()}
+	}
+	if req.Description != nil {
+		patch.Description = database.OptionalString{Set: true, Value: req.GetDescription()}
+	}
+	if req.GetClearDescription() {
+		patch.Description = database.OptionalString{Set: true, Null: true}
+	}
+	err := patch.Validate()
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	payload, err := s.rh.UpdateGood(int(req.GetId()), int(req.GetProjectId()), int(req.GetVersion()), patch)
+	return goodReply(ctx, payload, err)
+}
+
+// RemoveGood помечаем товар удаленным
+func (s *Server) RemoveGood(ctx context.Context, req *goodspb.RemoveGoodRequest) (*goodspb.Good, error) {
+	payload, err := s.rh.RemoveGood(int(req.GetId()), int(req.GetProjectId()), int(req.GetVersion()))
+	return goodReply(ctx, payload, err)
+}
+
+// ReprioritizeGood перемещаем товар, в ответе товары с новыми позициями
+func (s *Server) ReprioritizeGood(ctx context.Context, req *goodspb.ReprioritizeGoodRequest) (*goodspb.ReprioritizeGoodResponse, error) {
+	move := database.Move{}
+	switch target := req.GetTarget().(type) {
+	case *goodspb.ReprioritizeGoodRequest_Priority:
+		move.Priority = int(target.Priority)
+	case *goodspb.ReprioritizeGoodRequest_Before:
+		move.Before = int(target.Before)
+	case *goodspb.ReprioritizeGoodRequest_After:
+		move.After = int(target.After)
+	case *goodspb.ReprioritizeGoodRequest_Position:
+		move.Position = fromPosition(target.Position)
+	}
+	payload, err := s.rh.ReprioritizeGood(int(req.GetId()), int(req.GetProjectId()), int(req.GetVersion()), move)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	res := database.ReprioritiizeResponse{}
+	err = json.Unmarshal(payload, &res)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	out := &goodspb.ReprioritizeGoodResponse{}
+	for _, good := range res.Priorities {
+		out.Priorities = append(out.Priorities, toGood(good))
+	}
+	return out, nil
+}
+
+// goodReply ответ с одним товаром из JSON, который отдает RestHandler
+func goodReply(ctx context.Context, payload json.RawMessage, err error) (*goodspb.Good, error) {
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	good := database.Good{}
+	err = json.Unmarshal(payload, &good)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toGood(good), nil
+}
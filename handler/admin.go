@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"log"
+	"main/database"
+	natsLog "main/nats"
+	"net/http"
+	"strconv"
+)
+
+// PrioritiesHandler обработчик проверки приоритетов, projectId необязателен
+func (rh RestHandler) PrioritiesHandler(w http.ResponseWriter, r *http.Request) {
+	pID, err := getOptionalProjectID(param(r, "projectId"))
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+
+	payload, err := database.CheckPriorities(rh.DataBase, pID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(200)
+	w.Write(payload)
+}
+
+// CompactPrioritiesHandler обработчик плотной перенумерации приоритетов, projectId необязателен
+func (rh RestHandler) CompactPrioritiesHandler(w http.ResponseWriter, r *http.Request) {
+	pID, err := getOptionalProjectID(param(r, "projectId"))
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+
+	payload, logPayload, err := database.CompactPriorities(rh.DataBase, pID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	rh.afterCompact(logPayload)
+	w.WriteHeader(200)
+	w.Write(payload)
+}
+
+// afterCompact сбрасываем кеш и пишем в лог перенумерованные товары
+func (rh RestHandler) afterCompact(logPayload []natsLog.LogMessage) {
+	if len(logPayload) == 0 {
+		return
+	}
+	rh.invalidateProjects(logPayload)
+	err := natsLog.SendLogBatch(rh.Nats, logPayload)
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// invalidateProjects сбрасываем кеш списков и кеш отдельных товаров каждого проекта из лога по одному разу:
+// позиции сдвинулись у всех товаров этих проектов, а не только у попавших в лог
+func (rh RestHandler) invalidateProjects(logPayload []natsLog.LogMessage) {
+	err := database.InvalidateCache(rh.Redis)
+	if err != nil {
+		log.Print(err)
+	}
+	projects := map[int]bool{}
+	for _, msg := range logPayload {
+		if projects[msg.ProjectID] {
+			continue
+		}
+		projects[msg.ProjectID] = true
+		err = database.InvalidateProjectGoodsCache(rh.Redis, msg.ProjectID)
+		if err != nil {
+			log.Print(err)
+		}
+	}
+}
+
+// CompactPriorities перенумерация приоритетов вне http, например из командной строки
+func (rh RestHandler) CompactPriorities(pID int) (payload []byte, err error) {
+	payload, logPayload, err := database.CompactPriorities(rh.DataBase, pID)
+	if err != nil {
+		return nil, err
+	}
+	rh.afterCompact(logPayload)
+	return payload, nil
+}
+
+// getOptionalProjectID получаем projectId, 0 если не передан
+func getOptionalProjectID(spID string) (int, error) {
+	if spID == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(spID)
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"main/database"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// listCacheControl браузер и CDN могут хранить список, но перед использованием сверяют его по ETag
+const listCacheControl = "public, no-cache"
+
+// writeCachedPage отдаем страницу списка с заголовками для HTTP-кеширования
+// и отвечаем 304, если у клиента уже есть актуальная версия
+func writeCachedPage(w http.ResponseWriter, r *http.Request, cached database.CachedPage) {
+	w.Header().Set("ETag", cached.ETag)
+	w.Header().Set("Last-Modified", cached.LastModified.Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", listCacheControl)
+
+	if notModified(r, cached) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(200)
+	w.Write(cached.Payload)
+}
+
+// notModified проверяем If-None-Match, а если его нет, то If-Modified-Since (RFC 9110, 13.2.2)
+func notModified(r *http.Request, cached database.CachedPage) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagListMatches(inm, cached.ETag)
+	}
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !cached.LastModified.After(ims.Truncate(time.Second))
+}
+
+// etagListMatches слабое сравнение ETag из списка If-None-Match с текущим
+func etagListMatches(list, current string) bool {
+	current = strings.TrimPrefix(current, "W/")
+	for _, tag := range strings.Split(list, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == current {
+			return true
+		}
+	}
+	return false
+}
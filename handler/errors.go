@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"main/database"
+	"main/i18n"
+	"net/http"
+
+	"github.com/lib/pq"
+)
+
+// ErrorCode код ошибки в ответе, клиенты могут на него опираться
+type ErrorCode string
+
+// коды ошибок, список не меняется, только дополняется
+const (
+	CodeValidation ErrorCode = "validation"
+	CodeNotFound   ErrorCode = "not_found"
+	CodeConflict   ErrorCode = "conflict"
+	CodeInternal   ErrorCode = "internal"
+)
+
+// Error ошибка, которая отдается клиенту телом {"code", "key", "message", "details"}.
+// key - ключ сообщения в каталоге i18n, message - текст на языке из Accept-Language
+type Error struct {
+	Status  int             `json:"-"`
+	Code    ErrorCode       `json:"code"`
+	Key     string          `json:"key"`
+	Message string          `json:"message"`
+	Details json.RawMessage `json:"details"`
+}
+
+// Error текст ошибки
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// errMethodNotAllowed ответ на запрос с неподходящим методом
+var errMethodNotAllowed = &Error{Status: http.StatusMethodNotAllowed, Code: CodeValidation, Key: "errors.common.methodNotAllowed", Message: "method not allowed"}
+
+// ошибки разбора параметров запроса
+var (
+	errIDNotProvided        = errors.New("id not provided")
+	errProjectIDNotProvided = errors.New("projectId not provided")
+	errNameNotProvided      = errors.New("name not provided")
+	errIDsNotProvided       = errors.New("ids not provided")
+	errPatchNotObject       = errors.New("merge patch must be a JSON object")
+)
+
+// errBatchTooLarge в пачке больше элементов, чем разрешено в конфиге
+var errBatchTooLarge = &Error{Status: http.StatusBadRequest, Code: CodeValidation, Key: "errors.validation.batchTooLarge", Message: "batch is too large"}
+
+// limitDetails детали ошибки ограничения
+func limitDetails(value, max int) json.RawMessage {
+	out, _ := json.Marshal(map[string]int{"value": value, "max": max})
+	return out
+}
+
+// knownErrors статус, код и ключ сообщения для известных ошибок
+var knownErrors = []struct {
+	err    error
+	status int
+	code   ErrorCode
+	key    string
+}{
+	{database.ErrNotFound, http.StatusNotFound, CodeNotFound, "errors.common.notFound"},
+	{database.ErrProjectNotFound, http.StatusNotFound, CodeNotFound, "errors.project.notFound"},
+	{database.ErrAnchorNotFound, http.StatusUnprocessableEntity, CodeNotFound, "errors.good.anchorNotFound"},
+	{database.ErrNoRankGap, http.StatusConflict, CodeConflict, "errors.good.noRankGap"},
+	{database.ErrVersionMismatch, http.StatusPreconditionFailed, CodeConflict, "errors.common.preconditionFailed"},
+	{database.ErrProjectNotEmpty, http.StatusConflict, CodeConflict, "errors.project.notEmpty"},
+	{database.ErrBadPatch, http.StatusUnprocessableEntity, CodeValidation, "errors.validation.badPatch"},
+	{database.ErrBadCursor, http.StatusBadRequest, CodeValidation, "errors.validation.badCursor"},
+	{database.ErrLimitNotPositive, http.StatusBadRequest, CodeValidation, "errors.validation.limitPositive"},
+	{database.ErrBadSort, http.StatusBadRequest, CodeValidation, "errors.validation.badSort"},
+	{database.ErrBadMove, http.StatusBadRequest, CodeValidation, "errors.validation.badMove"},
+	{database.ErrBadReorder, http.StatusBadRequest, CodeValidation, "errors.validation.badReorder"},
+	{database.ErrBadBatch, http.StatusBadRequest, CodeValidation, "errors.validation.badBatch"},
+	{database.ErrNameRequired, http.StatusBadRequest, CodeValidation, "errors.validation.nameRequired"},
+	{database.ErrNegativePriority, http.StatusBadRequest, CodeValidation, "errors.validation.priorityNegative"},
+	{database.ErrBadPosition, http.StatusBadRequest, CodeValidation, "errors.validation.positionInvalid"},
+	{database.ErrPriorityOrPosition, http.StatusBadRequest, CodeValidation, "errors.validation.priorityOrPosition"},
+	{database.ErrWebhookNotFound, http.StatusNotFound, CodeNotFound, "errors.webhook.notFound"},
+	{database.ErrDeliveryNotFound, http.StatusNotFound, CodeNotFound, "errors.webhook.deliveryNotFound"},
+	{database.ErrWebhookInactive, http.StatusConflict, CodeConflict, "errors.webhook.inactive"},
+	{database.ErrBadWebhookURL, http.StatusBadRequest, CodeValidation, "errors.validation.webhookURL"},
+	{database.ErrBadWebhookEvent, http.StatusBadRequest, CodeValidation, "errors.validation.webhookEvent"},
+	{errBadIfMatch, http.StatusBadRequest, CodeValidation, "errors.validation.badIfMatch"},
+	{errIDNotProvided, http.StatusBadRequest, CodeValidation, "errors.validation.idRequired"},
+	{errProjectIDNotProvided, http.StatusBadRequest, CodeValidation, "errors.validation.projectIdRequired"},
+	{errNameNotProvided, http.StatusBadRequest, CodeValidation, "errors.validation.nameRequired"},
+	{errIDsNotProvided, http.StatusBadRequest, CodeValidation, "errors.validation.idsRequired"},
+	{errPatchNotObject, http.StatusBadRequest, CodeValidation, "errors.validation.patchNotObject"},
+}
+
+// validationError ошибка разбора или проверки запроса. Известные ошибки сохраняют свой статус,
+// остальные (например, от strconv или encoding/json) отдаются как 400
+func validationError(err error) *Error {
+	e := ToError(err)
+	if e.Code == CodeInternal {
+		return &Error{Status: http.StatusBadRequest, Code: CodeValidation, Key: "errors.validation.badRequest", Message: err.Error(), Details: errorDetails(err.Error())}
+	}
+	return e
+}
+
+// withStatus та же ошибка с другим статусом ответа
+func withStatus(err error, status int) *Error {
+	e := ToError(err)
+	e.Status = status
+	return e
+}
+
+// withDetails та же ошибка с деталями
+func withDetails(err error, details json.RawMessage) *Error {
+	e := ToError(err)
+	e.Details = details
+	return e
+}
+
+// ToError приводим ошибку к Error. Текст неизвестных ошибок клиенту не отдается,
+// в нем могут быть подробности запроса к базе
+func ToError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		out := *e
+		return &out
+	}
+	for _, known := range knownErrors {
+		if errors.Is(err, known.err) {
+			e := &Error{Status: known.status, Code: known.code, Key: known.key, Message: err.Error()}
+			// в обернутой ошибке есть подробности, например неизвестное поле сортировки
+			if e.Message != known.err.Error() {
+				e.Details = errorDetails(e.Message)
+			}
+			return e
+		}
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		if e := constraintError(pqErr); e != nil {
+			return e
+		}
+	}
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Key: "errors.common.internal", Message: "internal error"}
+}
+
+// constraintError ошибка нарушения ограничений или неверных данных Postgres, nil для остальных
+func constraintError(pqErr *pq.Error) *Error {
+	details, _ := json.Marshal(map[string]string{
+		"reason":     pqErr.Code.Name(),
+		"constraint": pqErr.Constraint,
+		"column":     pqErr.Column,
+	})
+	switch pqErr.Code.Name() {
+	case "unique_violation":
+		return &Error{Status: http.StatusConflict, Code: CodeConflict, Key: "errors.db.alreadyExists", Message: "already exists", Details: details}
+	case "foreign_key_violation":
+		return &Error{Status: http.StatusConflict, Code: CodeConflict, Key: "errors.db.reference", Message: "referenced object is missing or still in use", Details: details}
+	case "not_null_violation", "check_violation":
+		return &Error{Status: http.StatusUnprocessableEntity, Code: CodeValidation, Key: "errors.db.constraint", Message: "value violates a constraint", Details: details}
+	}
+	// класс 22 - data_exception: слишком длинная строка, неверный формат и т.п.
+	if pqErr.Code.Class() == "22" {
+		return &Error{Status: http.StatusBadRequest, Code: CodeValidation, Key: "errors.db.invalidValue", Message: "invalid value", Details: details}
+	}
+	return nil
+}
+
+// writeError пишем ошибку в ответ на языке из Accept-Language, внутренние ошибки пишутся в лог
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	e := ToError(err)
+	if e.Status >= http.StatusInternalServerError {
+		log.Print(err)
+	}
+	lang := requestLang(r)
+	if i18n.Has(e.Key) {
+		e.Message = i18n.Message(lang, e.Key)
+	}
+	if len(e.Details) == 0 {
+		e.Details = json.RawMessage("{}")
+	}
+	out, mErr := json.Marshal(e)
+	if mErr != nil {
+		log.Print(mErr)
+		out = []byte(`{"code":"internal","key":"errors.common.internal","message":"internal error","details":{}}`)
+		e.Status = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", lang)
+	w.WriteHeader(e.Status)
+	w.Write(out)
+}
+
+// errorDetails детали с исходным текстом ошибки
+func errorDetails(message string) json.RawMessage {
+	out, _ := json.Marshal(map[string]string{"error": message})
+	return out
+}
+
+// requestLang язык ответа по заголовку Accept-Language
+func requestLang(r *http.Request) string {
+	return i18n.Lang(r.Header.Get("Accept-Language"))
+}
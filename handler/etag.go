@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"main/database"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// errBadIfMatch сообщение если If-Match не разобрать
+var errBadIfMatch = errors.New("If-Match must be * or a single strong ETag")
+
+// etag ETag товара по его версии
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// setETag выставляем ETag по версии товара из тела ответа
+func setETag(w http.ResponseWriter, payload []byte) {
+	v := struct {
+		Version int `json:"version"`
+	}{}
+	if json.Unmarshal(payload, &v) == nil && v.Version > 0 {
+		w.Header().Set("ETag", etag(v.Version))
+	}
+}
+
+// getIfMatch ожидаемая версия товара из If-Match, database.AnyVersion если заголовка нет или он *.
+// Слабый ETag по RFC 9110 никогда не совпадает при If-Match, поэтому для него версия -1
+func getIfMatch(r *http.Request) (int, error) {
+	h := strings.TrimSpace(r.Header.Get("If-Match"))
+	if h == "" || h == "*" {
+		return database.AnyVersion, nil
+	}
+	if strings.HasPrefix(h, "W/") {
+		return -1, nil
+	}
+	s, err := strconv.Unquote(h)
+	if err != nil {
+		return 0, errBadIfMatch
+	}
+	version, err := strconv.Atoi(s)
+	if err != nil || version <= 0 {
+		return -1, nil
+	}
+	return version, nil
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	natsLog "main/nats"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// feedBuffer сколько событий ждут отправки клиенту, клиент, который не успевает, отключается
+const feedBuffer = 256
+
+// feedPing как часто отправлять комментарий, чтобы прокси не закрывали соединение
+const feedPing = 15 * time.Second
+
+// Feed раздает события из лога NATS подписанным по SSE клиентам
+type Feed struct {
+	mu      sync.Mutex
+	clients map[chan natsLog.LogMessage]int
+}
+
+// NewFeed получаем ленту событий без подписки на NATS
+func NewFeed() *Feed {
+	return &Feed{clients: map[chan natsLog.LogMessage]int{}}
+}
+
+// Subscribe подписываем ленту на лог NATS, так события получают клиенты всех экземпляров сервиса
+func (f *Feed) Subscribe(nc *nats.Conn) (*nats.Subscription, error) {
+	return natsLog.Subscribe(nc, f.Publish)
+}
+
+// Publish отправляем события клиентам, которые следят за их проектом или за всеми проектами
+func (f *Feed) Publish(msgs []natsLog.LogMessage) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch, pID := range f.clients {
+	client:
+		for _, msg := range msgs {
+			if pID != 0 && msg.ProjectID != pID {
+				continue
+			}
+			select {
+			case ch <- msg:
+			default:
+				// клиент отстал, закрываем поток, чтобы он переподключился и перечитал список
+				delete(f.clients, ch)
+				close(ch)
+				break client
+			}
+		}
+	}
+}
+
+// add регистрируем клиента проекта pID, 0 - все проекты
+func (f *Feed) add(pID int) chan natsLog.LogMessage {
+	ch := make(chan natsLog.LogMessage, feedBuffer)
+	f.mu.Lock()
+	f.clients[ch] = pID
+	f.mu.Unlock()
+	return ch
+}
+
+// remove отключаем клиента, если его еще не отключил Publish
+func (f *Feed) remove(ch chan natsLog.LogMessage) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.clients[ch]; ok {
+		delete(f.clients, ch)
+		close(ch)
+	}
+}
+
+// Handler поток событий Server-Sent Events. Проект задается путем или параметром projectId,
+// без него отдаются события всех проектов
+func (f *Feed) Handler(w http.ResponseWriter, r *http.Request) {
+	pID, err := getOptionalProjectID(param(r, "projectId"))
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	ch := f.add(pID)
+	defer f.remove(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(200)
+	// клиенту сразу видно, что подписка началась
+	fmt.Fprint(w, ": connected\n\n")
+	err = rc.Flush()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	ping := time.NewTicker(feedPing)
+	defer ping.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ping.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			out, err := json.Marshal(msg)
+			if err != nil {
+				log.Print(err)
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg.Event, out)
+		}
+		err = rc.Flush()
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"main/database"
+	natsLog "main/nats"
+)
+
+// Операции с товарами вне http: чтение через кеш, изменение со сбросом кеша и отправкой событий.
+// Их используют и REST-обработчики, и gRPC-сервер
+
+// ListGoods список товаров по фильтру, сначала из кеша
+func (rh RestHandler) ListGoods(filter database.GoodsFilter, page database.Page) (database.CachedPage, error) {
+	cached, err := database.FindInCache(rh.Redis, filter, page)
+	if err == nil {
+		return cached, nil
+	}
+	log.Print(err) // продолжаем
+
+	payload, err := database.FindGoods(rh.DataBase, filter, page)
+	if err != nil {
+		return cached, err
+	}
+	// пишем кеш
+	cached = database.NewCachedPage(payload)
+	err = database.PutInCache(rh.Redis, cached, filter, page)
+	if err != nil {
+		log.Print(err)
+	}
+	return cached, nil
+}
+
+// FindGood товар по id и projectId, сначала из кеша
+func (rh RestHandler) FindGood(ID, pID int) (payload json.RawMessage, err error) {
+	payload, err = database.FindGoodInCache(rh.Redis, ID, pID)
+	if payload != nil {
+		return payload, nil
+	}
+	log.Print(err) // продолжаем
+
+	payload, err = database.FindGood(rh.DataBase, ID, pID)
+	if err != nil {
+		return nil, err
+	}
+	// пишем кеш
+	err = database.PutGoodInCache(rh.Redis, payload, ID, pID)
+	if err != nil {
+		log.Print(err)
+	}
+	return payload, nil
+}
+
+// CreateGood добавляем товар в проект
+func (rh RestHandler) CreateGood(pID int, good database.NewGood) (payload json.RawMessage, err error) {
+	err = good.Validate()
+	if err != nil {
+		return nil, err
+	}
+	payload, logPayload, err := database.InsertGood(rh.DataBase, pID, good)
+	if err != nil {
+		return nil, err
+	}
+	err = database.InvalidateCache(rh.Redis)
+	if err != nil {
+		log.Print(err)
+	}
+	// товар вставлен не в конец, позиции остальных товаров проекта сдвинулись
+	if good.Priority != 0 || good.Position == database.PositionTop {
+		err = database.InvalidateProjectGoodsCache(rh.Redis, pID)
+		if err != nil {
+			log.Print(err)
+		}
+	}
+	err = natsLog.SendLog(rh.Nats, logPayload)
+	if err != nil {
+		log.Print(err)
+	}
+	return payload, nil
+}
+
+// CreateGoods добавляем пачку товаров в проект одной транзакцией, кеш сбрасывается один раз на всю пачку.
+// Ошибки по элементам приходят в *database.BatchError
+func (rh RestHandler) CreateGoods(pID int, goods []database.NewGood) (payload json.RawMessage, err error) {
+	err = rh.checkBatchSize(len(goods))
+	if err != nil {
+		return nil, err
+	}
+	payload, logPayload, err := database.InsertGoods(rh.DataBase, pID, goods)
+	if err != nil {
+		return nil, err
+	}
+	// позиции в проекте могли сдвинуться
+	err = database.InvalidateCache(rh.Redis)
+	if err != nil {
+		log.Print(err)
+	}
+	err = database.InvalidateProjectGoodsCache(rh.Redis, pID)
+	if err != nil {
+		log.Print(err)
+	}
+	err = natsLog.SendLogBatch(rh.Nats, logPayload)
+	if err != nil {
+		log.Print(err)
+	}
+	return payload, nil
+}
+
+// UpdateGood обновляем товар по merge patch
+func (rh RestHandler) UpdateGood(ID, pID, version int, patch database.GoodPatch) (payload json.RawMessage, err error) {
+	payload, logPayload, err := database.UpdateGood(rh.DataBase, ID, pID, version, patch)
+	if err != nil {
+		return nil, err
+	}
+	// пустой патч ничего не поменял
+	if logPayload != nil {
+		rh.afterGoodChange(ID, pID, logPayload)
+	}
+	return payload, nil
+}
+
+// RemoveGood помечаем товар удаленным
+func (rh RestHandler) RemoveGood(ID, pID, version int) (payload json.RawMessage, err error) {
+	payload, logPayload, err := database.DeleteGood(rh.DataBase, ID, pID, version)
+	if err != nil {
+		return nil, err
+	}
+	rh.afterGoodChange(ID, pID, logPayload)
+	return payload, nil
+}
+
+// RestoreGood восстанавливаем удаленный товар
+func (rh RestHandler) RestoreGood(ID, pID, version int) (payload json.RawMessage, err error) {
+	payload, logPayload, err := database.RestoreGood(rh.DataBase, ID, pID, version)
+	if err != nil {
+		return nil, err
+	}
+	rh.afterGoodChange(ID, pID, logPayload)
+	return payload, nil
+}
+
+// SetGoodsRemoved помечаем пачку товаров удаленными или восстанавливаем их одним запросом
+func (rh RestHandler) SetGoodsRemoved(pID int, IDs []int, removed bool) (payload json.RawMessage, err error) {
+	err = rh.checkBatchSize(len(IDs))
+	if err != nil {
+		return nil, err
+	}
+	payload, logPayload, err := database.SetGoodsRemoved(rh.DataBase, pID, IDs, removed)
+	if err != nil {
+		return nil, err
+	}
+	err = database.InvalidateCache(rh.Redis)
+	if err != nil {
+		log.Print(err)
+	}
+	for _, msg := range logPayload {
+		err = database.InvalidateGoodCache(rh.Redis, msg.ID, pID)
+		if err != nil {
+			log.Print(err)
+		}
+	}
+	err = natsLog.SendLogBatch(rh.Nats, logPayload)
+	if err != nil {
+		log.Print(err)
+	}
+	return payload, nil
+}
+
+// ReprioritizeGood перемещаем товар, в ответе товары с новыми позициями
+func (rh RestHandler) ReprioritizeGood(ID, pID, version int, move database.Move) (payload json.RawMessage, err error) {
+	err = move.Validate()
+	if err != nil {
+		return nil, err
+	}
+	payload, logPayload, err := database.ReprioritiizeGood(rh.DataBase, ID, pID, version, move)
+	if err != nil {
+		return nil, err
+	}
+	err = database.InvalidateCache(rh.Redis)
+	if err != nil {
+		log.Print(err)
+	}
+	// позиции сдвигаются у всего проекта, поэтому сбрасываем кеш всех его товаров
+	err = database.InvalidateProjectGoodsCache(rh.Redis, pID)
+	if err != nil {
+		log.Print(err)
+	}
+
+	// пишем в лог
+	for _, msg := range logPayload {
+		out, err := json.Marshal(msg)
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+		err = natsLog.SendLog(rh.Nats, out)
+		if err != nil {
+			log.Print(err)
+		}
+	}
+	return payload, nil
+}
+
+// afterGoodChange сбрасываем кеш и пишем в лог изменение одного товара
+func (rh RestHandler) afterGoodChange(ID, pID int, logPayload json.RawMessage) {
+	err := database.InvalidateCache(rh.Redis)
+	if err != nil {
+		log.Print(err)
+	}
+	err = database.InvalidateGoodCache(rh.Redis, ID, pID)
+	if err != nil {
+		log.Print(err)
+	}
+	err = natsLog.SendLog(rh.Nats, logPayload)
+	if err != nil {
+		log.Print(err)
+	}
+}
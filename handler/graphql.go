@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"main/i18n"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// GraphQLConfig ограничения запросов GraphQL
+type GraphQLConfig struct {
+	// MaxDepth наибольшая вложенность полей
+	MaxDepth int `yaml:"maxDepth"`
+	// MaxComplexity наибольшая сложность: каждое поле стоит 1, поля внутри списка умножаются на его limit,
+	// без limit - на 10
+	MaxComplexity int `yaml:"maxComplexity"`
+}
+
+// GraphQL обработчик /graphql
+type GraphQL struct {
+	schema        graphql.Schema
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// defaultListSize limit списков товаров, проектов и истории, если он не передан
+const defaultListSize = 10
+
+// NewGraphQL получаем обработчик GraphQL, по умолчанию вложенность до 8 и сложность до 1000
+func NewGraphQL(rh RestHandler, cfg GraphQLConfig) (GraphQL, error) {
+	schema, err := newSchema(rh)
+	if err != nil {
+		return GraphQL{}, err
+	}
+	g := GraphQL{
+		schema:        schema,
+		MaxDepth:      cfg.MaxDepth,
+		MaxComplexity: cfg.MaxComplexity,
+	}
+	if g.MaxDepth <= 0 {
+		g.MaxDepth = 8
+	}
+	if g.MaxComplexity <= 0 {
+		g.MaxComplexity = 1000
+	}
+	return g, nil
+}
+
+// graphQLRequest тело запроса GraphQL
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// langKey ключ контекста с языком ответа
+type langKey struct{}
+
+// Handler обработчик запросов GraphQL. Запрос, который не разобрать, не прошел проверку схемы
+// или превысил ограничения, получает 400, остальные - 200 с ошибками полей в errors
+func (g GraphQL) Handler(w http.ResponseWriter, r *http.Request) {
+	req := graphQLRequest{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+	lang := requestLang(r)
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})})
+	if err != nil {
+		writeGraphQL(w, lang, http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+		return
+	}
+	validation := graphql.ValidateDocument(&g.schema, doc, nil)
+	if !validation.IsValid {
+		writeGraphQL(w, lang, http.StatusBadRequest, &graphql.Result{Errors: validation.Errors})
+		return
+	}
+	err = g.checkLimits(doc, req.OperationName, req.Variables, lang)
+	if err != nil {
+		// исходная ошибка нужна, чтобы попали extensions
+		limitErr := gqlerrors.NewError(err.Error(), nil, "", nil, nil, err)
+		writeGraphQL(w, lang, http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(limitErr)})
+		return
+	}
+
+	res := graphql.Execute(graphql.ExecuteParams{
+		Schema:        g.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       context.WithValue(r.Context(), langKey{}, lang),
+	})
+	writeGraphQL(w, lang, http.StatusOK, res)
+}
+
+// writeGraphQL пишем ответ GraphQL
+func writeGraphQL(w http.ResponseWriter, lang string, status int, res *graphql.Result) {
+	out, err := json.Marshal(res)
+	if err != nil {
+		log.Print(err)
+		out = []byte(`{"errors":[{"message":"internal error"}]}`)
+		status = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", lang)
+	w.WriteHeader(status)
+	w.Write(out)
+}
+
+// gqlError ошибка поля, код и ключ отдаются в extensions, как code и key в Error
+type gqlError struct {
+	e *Error
+}
+
+// Error текст ошибки
+func (g gqlError) Error() string {
+	return g.e.Message
+}
+
+// Extensions код, ключ и детали ошибки
+func (g gqlError) Extensions() map[string]any {
+	ext := map[string]any{"code": g.e.Code, "key": g.e.Key}
+	if len(g.e.Details) > 0 {
+		ext["details"] = g.e.Details
+	}
+	return ext
+}
+
+// graphQLError приводим ошибку к Error и переводим на язык запроса, внутренние ошибки пишутся в лог
+func graphQLError(ctx context.Context, err error) error {
+	e := ToError(err)
+	if e.Status >= http.StatusInternalServerError {
+		log.Print(err)
+	}
+	lang, _ := ctx.Value(langKey{}).(string)
+	if i18n.Has(e.Key) {
+		e.Message = i18n.Message(lang, e.Key)
+	}
+	return gqlError{e: e}
+}
+
+// errQueryTooDeep и errQueryTooComplex запрос превышает ограничения
+var (
+	errQueryTooDeep    = &Error{Status: http.StatusBadRequest, Code: CodeValidation, Key: "errors.graphql.tooDeep", Message: "query is too deep"}
+	errQueryTooComplex = &Error{Status: http.StatusBadRequest, Code: CodeValidation, Key: "errors.graphql.tooComplex", Message: "query is too complex"}
+)
+
+// checkLimits проверяем вложенность и сложность выполняемой операции
+func (g GraphQL) checkLimits(doc *ast.Document, operationName string, variables map[string]any, lang string) error {
+	c := complexity{fragments: map[string]*ast.FragmentDefinition{}, variables: variables, defaults: map[string]ast.Value{}}
+	var operation *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			c.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+				operation = def
+			}
+		}
+	}
+	if operation == nil {
+		return nil
+	}
+	for _, def := range operation.VariableDefinitions {
+		if def.DefaultValue != nil {
+			c.defaults[def.Variable.Name.Value] = def.DefaultValue
+		}
+	}
+	cost, depth := c.selectionSet(operation.SelectionSet, "", 1, 1)
+	ctx := context.WithValue(context.Background(), langKey{}, lang)
+	if depth > g.MaxDepth {
+		return graphQLError(ctx, withDetails(errQueryTooDeep, limitDetails(depth, g.MaxDepth)))
+	}
+	if cost > g.MaxComplexity {
+		return graphQLError(ctx, withDetails(errQueryTooComplex, limitDetails(cost, g.MaxComplexity)))
+	}
+	return nil
+}
+
+// maxListSize больше элементов в списке не бывает: Int в GraphQL 32-битный.
+// Ограничение не дает переполниться произведению вложенных списков
+const maxListSize = math.MaxInt32
+
+// complexity подсчет сложности запроса по его дереву
+type complexity struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+	// defaults значения переменных по умолчанию из объявления операции
+	defaults map[string]ast.Value
+	// visited фрагменты на текущем пути, чтобы не уйти в цикл
+	visited map[string]bool
+}
+
+// selectionSet сложность и вложенность набора полей поля parent, каждое поле набора повторяется multiplier раз
+func (c *complexity) selectionSet(set *ast.SelectionSet, parent string, multiplier, depth int) (cost, maxDepth int) {
+	if set == nil {
+		return 0, depth - 1
+	}
+	maxDepth = depth
+	for _, selection := range set.Selections {
+		var childCost, childDepth int
+		switch s := selection.(type) {
+		case *ast.Field:
+			childCost, childDepth = c.selectionSet(s.SelectionSet, s.Name.Value, min(multiplier*c.listSize(s, parent), maxListSize), depth+1)
+			childCost += multiplier
+		case *ast.InlineFragment:
+			childCost, childDepth = c.selectionSet(s.SelectionSet, parent, multiplier, depth)
+		case *ast.FragmentSpread:
+			name := s.Name.Value
+			fragment, ok := c.fragments[name]
+			if !ok || c.visited[name] {
+				continue
+			}
+			if c.visited == nil {
+				c.visited = map[string]bool{}
+			}
+			c.visited[name] = true
+			childCost, childDepth = c.selectionSet(fragment.SelectionSet, parent, multiplier, depth)
+			delete(c.visited, name)
+		}
+		cost += childCost
+		maxDepth = max(maxDepth, childDepth)
+	}
+	return cost, maxDepth
+}
+
+// listSize сколько раз повторяются вложенные поля: limit списка товаров, проектов или истории так же,
+// как его получит резолвер - из запроса, переменной, значения переменной по умолчанию или defaultListSize.
+// goods внутри страницы - это ее товары, они уже посчитаны по limit страницы
+func (c *complexity) listSize(field *ast.Field, parent string) int {
+	switch {
+	case field.Name.Value == "goods" && parent != "goods":
+	case field.Name.Value == "projects", field.Name.Value == "history":
+	default:
+		return 1
+	}
+	for _, arg := range field.Arguments {
+		if arg.Name.Value == "limit" {
+			return c.intValue(arg.Value, defaultListSize)
+		}
+	}
+	return defaultListSize
+}
+
+// intValue положительное число из значения аргумента или fallback, если значения нет.
+// Резолвер отклоняет limit меньше 1, такой список считаем как fallback
+func (c *complexity) intValue(value ast.Value, fallback int) int {
+	var n float64
+	switch v := value.(type) {
+	case *ast.IntValue:
+		i, err := strconv.ParseFloat(v.Value, 64)
+		if err != nil {
+			return fallback
+		}
+		n = i
+	case *ast.Variable:
+		name := v.Name.Value
+		switch val := c.variables[name].(type) {
+		case float64:
+			n = val
+		case int:
+			n = float64(val)
+		case nil:
+			if def, ok := c.defaults[name]; ok {
+				return c.intValue(def, fallback)
+			}
+			return fallback
+		default:
+			return fallback
+		}
+	default:
+		return fallback
+	}
+	if n < 1 {
+		return fallback
+	}
+	return int(min(n, maxListSize))
+}
@@ -0,0 +1,373 @@
+package handler
+
+import (
+	"encoding/json"
+	"main/database"
+
+	"github.com/graphql-go/graphql"
+)
+
+// positionEnum начало или конец проекта, значения совпадают с database.PositionTop и database.PositionBottom
+var positionEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "Position",
+	Values: graphql.EnumValueConfigMap{
+		"TOP":    &graphql.EnumValueConfig{Value: database.PositionTop},
+		"BOTTOM": &graphql.EnumValueConfig{Value: database.PositionBottom},
+	},
+})
+
+// goodType товар, поля берутся по json-тегам database.Good
+var goodType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Good",
+	Fields: graphql.Fields{
+		"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"projectId":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"name":        &graphql.Field{Type: graphql.String},
+		"description": &graphql.Field{Type: graphql.String},
+		"priority":    &graphql.Field{Type: graphql.Int},
+		"removed":     &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"createdAt":   &graphql.Field{Type: graphql.DateTime},
+		"version":     &graphql.Field{Type: graphql.Int},
+	},
+})
+
+// metaType метаданные списка, как meta в ответе GET /v1/goods
+var metaType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Meta",
+	Fields: graphql.Fields{
+		"total":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"removed":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"limit":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"offset":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"cursor":     &graphql.Field{Type: graphql.String},
+		"nextCursor": &graphql.Field{Type: graphql.String},
+	},
+})
+
+// goodsPageType страница списка товаров
+var goodsPageType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "GoodsPage",
+	Fields: graphql.Fields{
+		"meta":  &graphql.Field{Type: graphql.NewNonNull(metaType)},
+		"goods": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(goodType)))},
+	},
+})
+
+// historyEntryType запись истории товара, поля берутся по json-тегам database.HistoryEntry
+var historyEntryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "HistoryEntry",
+	Fields: graphql.Fields{
+		"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"goodId":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"projectId":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"event":       &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "create, update, remove, restore, reprioritize или purge"},
+		"name":        &graphql.Field{Type: graphql.String},
+		"description": &graphql.Field{Type: graphql.String},
+		"priority":    &graphql.Field{Type: graphql.Int},
+		"removed":     &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"version":     &graphql.Field{Type: graphql.Int},
+		"eventTime":   &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+	},
+})
+
+// historyArgs размер истории
+var historyArgs = graphql.FieldConfigArgument{
+	"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultListSize},
+}
+
+// goodsArgs фильтр и страница списка, как параметры GET /v1/goods
+var goodsArgs = graphql.FieldConfigArgument{
+	"removed":     &graphql.ArgumentConfig{Type: graphql.Boolean},
+	"search":      &graphql.ArgumentConfig{Type: graphql.String},
+	"createdFrom": &graphql.ArgumentConfig{Type: graphql.String, Description: "RFC3339 или 2006-01-02"},
+	"createdTo":   &graphql.ArgumentConfig{Type: graphql.String, Description: "RFC3339 или 2006-01-02"},
+	"sort":        &graphql.ArgumentConfig{Type: graphql.String, Description: "id, priority или createdAt, с минусом - по убыванию"},
+	"limit":       &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultListSize},
+	"offset":      &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0, Description: "сколько товаров пропустить"},
+	"cursor":      &graphql.ArgumentConfig{Type: graphql.String, Description: "наличие курсора включает выдачу по курсору, пустой - первая страница"},
+}
+
+// newSchema схема GraphQL поверх методов RestHandler
+func newSchema(rh RestHandler) (graphql.Schema, error) {
+	projectType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Project",
+		Fields: graphql.Fields{
+			"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"name":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"createdAt": &graphql.Field{Type: graphql.DateTime},
+			"goods": &graphql.Field{
+				Type: graphql.NewNonNull(goodsPageType),
+				Args: goodsArgs,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					project := p.Source.(database.Project)
+					return rh.resolveGoods(p, project.ID)
+				},
+			},
+			"history": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(historyEntryType))),
+				Args:        historyArgs,
+				Description: "последние limit изменений товаров проекта, новые сначала",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					project := p.Source.(database.Project)
+					return rh.resolveHistory(p, 0, project.ID)
+				},
+			},
+		},
+	})
+	// товар объявлен вне схемы, а истории нужен доступ к базе
+	goodType.AddFieldConfig("history", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(historyEntryType))),
+		Args:        historyArgs,
+		Description: "последние limit изменений товара, новые сначала",
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			good := p.Source.(database.Good)
+			return rh.resolveHistory(p, good.ID, good.ProjectID)
+		},
+	})
+
+	goodArgs := graphql.FieldConfigArgument{
+		"projectId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+		"id":        &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+	}
+	changeArgs := withArgs(goodArgs, graphql.FieldConfigArgument{
+		"version": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: database.AnyVersion, Description: "ожидаемая версия товара, 0 - любая"},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"goods": &graphql.Field{
+				Type: graphql.NewNonNull(goodsPageType),
+				Args: withArgs(goodsArgs, graphql.FieldConfigArgument{
+					"projectId": &graphql.ArgumentConfig{Type: graphql.Int},
+				}),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					pID, _ := p.Args["projectId"].(int)
+					return rh.resolveGoods(p, pID)
+				},
+			},
+			"good": &graphql.Field{
+				Type: goodType,
+				Args: goodArgs,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					payload, err := rh.FindGood(p.Args["id"].(int), p.Args["projectId"].(int))
+					return goodResult(p, payload, err)
+				},
+			},
+			"projects": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(projectType))),
+				Args: graphql.FieldConfigArgument{
+					"limit":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultListSize},
+					"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0, Description: "сколько проектов пропустить"},
+				},
+				Description: "проекты по порядку id, не больше limit",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					limit, offset := p.Args["limit"].(int), p.Args["offset"].(int)
+					if limit <= 0 {
+						return nil, graphQLError(p.Context, database.ErrLimitNotPositive)
+					}
+					projects, err := database.FindProjectsPage(rh.DataBase, limit, offset)
+					if err != nil {
+						return nil, graphQLError(p.Context, err)
+					}
+					return projects, nil
+				},
+			},
+			"project": &graphql.Field{
+				Type: projectType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					project, err := database.FindProject(rh.DataBase, p.Args["id"].(int))
+					if err != nil {
+						return nil, graphQLError(p.Context, err)
+					}
+					return project, nil
+				},
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createGood": &graphql.Field{
+				Type: goodType,
+				Args: graphql.FieldConfigArgument{
+					"projectId":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"name":        &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"description": &graphql.ArgumentConfig{Type: graphql.String},
+					"priority":    &graphql.ArgumentConfig{Type: graphql.Int},
+					"position":    &graphql.ArgumentConfig{Type: positionEnum},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					good := database.NewGood{Name: p.Args["name"].(string)}
+					if description, ok := p.Args["description"].(string); ok {
+						good.Description = &description
+					}
+					good.Priority, _ = p.Args["priority"].(int)
+					good.Position, _ = p.Args["position"].(string)
+					payload, err := rh.CreateGood(p.Args["projectId"].(int), good)
+					return goodResult(p, payload, err)
+				},
+			},
+			"updateGood": &graphql.Field{
+				Type: goodType,
+				Args: withArgs(changeArgs, graphql.FieldConfigArgument{
+					"name":             &graphql.ArgumentConfig{Type: graphql.String},
+					"description":      &graphql.ArgumentConfig{Type: graphql.String},
+					"clearDescription": &graphql.ArgumentConfig{Type: graphql.Boolean, Description: "убрать описание"},
+				}),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					patch := database.GoodPatch{}
+					if name, ok := p.Args["name"].(string); ok {
+						patch.Name = database.OptionalString{Set: true, Value: name}
+					}
+					if description, ok := p.Args["description"].(string); ok {
+						patch.Description = database.OptionalString{Set: true, Value: description}
+					}
+					if clear, _ := p.Args["clearDescription"].(bool); clear {
+						patch.Description = database.OptionalString{Set: true, Null: true}
+					}
+					err := patch.Validate()
+					if err != nil {
+						return nil, graphQLError(p.Context, err)
+					}
+					payload, err := rh.UpdateGood(p.Args["id"].(int), p.Args["projectId"].(int), p.Args["version"].(int), patch)
+					return goodResult(p, payload, err)
+				},
+			},
+			"removeGood": &graphql.Field{
+				Type: goodType,
+				Args: changeArgs,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					payload, err := rh.RemoveGood(p.Args["id"].(int), p.Args["projectId"].(int), p.Args["version"].(int))
+					return goodResult(p, payload, err)
+				},
+			},
+			"restoreGood": &graphql.Field{
+				Type: goodType,
+				Args: changeArgs,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					payload, err := rh.RestoreGood(p.Args["id"].(int), p.Args["projectId"].(int), p.Args["version"].(int))
+					return goodResult(p, payload, err)
+				},
+			},
+			"reprioritizeGood": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(goodType))),
+				Args: withArgs(changeArgs, graphql.FieldConfigArgument{
+					"priority": &graphql.ArgumentConfig{Type: graphql.Int},
+					"before":   &graphql.ArgumentConfig{Type: graphql.Int},
+					"after":    &graphql.ArgumentConfig{Type: graphql.Int},
+					"position": &graphql.ArgumentConfig{Type: positionEnum},
+				}),
+				Description: "нужно передать ровно одно из priority, before, after или position, в ответе товары с новыми позициями",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					move := database.Move{}
+					move.Priority, _ = p.Args["priority"].(int)
+					move.Before, _ = p.Args["before"].(int)
+					move.After, _ = p.Args["after"].(int)
+					move.Position, _ = p.Args["position"].(string)
+					payload, err := rh.ReprioritizeGood(p.Args["id"].(int), p.Args["projectId"].(int), p.Args["version"].(int), move)
+					if err != nil {
+						return nil, graphQLError(p.Context, err)
+					}
+					res := database.ReprioritiizeResponse{}
+					err = json.Unmarshal(payload, &res)
+					if err != nil {
+						return nil, graphQLError(p.Context, err)
+					}
+					return res.Priorities, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+// resolveGoods страница товаров проекта pID (0 - всех проектов) по аргументам goodsArgs
+func (rh RestHandler) resolveGoods(p graphql.ResolveParams, pID int) (res any, err error) {
+	filter := database.GoodsFilter{ProjectID: pID}
+	if removed, ok := p.Args["removed"].(bool); ok {
+		filter.Removed = &removed
+	}
+	filter.Search, _ = p.Args["search"].(string)
+	filter.Sort, _ = p.Args["sort"].(string)
+	createdFrom, _ := p.Args["createdFrom"].(string)
+	filter.CreatedFrom, err = parseTime(createdFrom)
+	if err != nil {
+		return nil, graphQLError(p.Context, validationError(err))
+	}
+	createdTo, _ := p.Args["createdTo"].(string)
+	filter.CreatedTo, err = parseTime(createdTo)
+	if err != nil {
+		return nil, graphQLError(p.Context, validationError(err))
+	}
+	err = filter.Validate()
+	if err != nil {
+		return nil, graphQLError(p.Context, err)
+	}
+
+	page := database.Page{}
+	page.Limit, _ = p.Args["limit"].(int)
+	page.Offset, _ = p.Args["offset"].(int)
+	// limit задает и размер ответа, и сложность запроса, поэтому пустых и отрицательных не принимаем
+	if page.Limit <= 0 {
+		return nil, graphQLError(p.Context, database.ErrLimitNotPositive)
+	}
+	if cursor, ok := p.Args["cursor"].(string); ok {
+		page.Keyset = true
+		page.Cursor = cursor
+		page.Offset = 0
+	}
+
+	cached, err := rh.ListGoods(filter, page)
+	if err != nil {
+		return nil, graphQLError(p.Context, err)
+	}
+	goods := database.GoodsResponse{}
+	err = json.Unmarshal(cached.Payload, &goods)
+	if err != nil {
+		return nil, graphQLError(p.Context, err)
+	}
+	return goods, nil
+}
+
+// resolveHistory история товара ID проекта pID (0 - всех товаров проекта) по аргументам historyArgs
+func (rh RestHandler) resolveHistory(p graphql.ResolveParams, ID, pID int) (any, error) {
+	limit, _ := p.Args["limit"].(int)
+	if limit <= 0 {
+		return nil, graphQLError(p.Context, database.ErrLimitNotPositive)
+	}
+	entries, err := database.FindHistory(rh.DataBase, ID, pID, limit)
+	if err != nil {
+		return nil, graphQLError(p.Context, err)
+	}
+	return entries, nil
+}
+
+// goodResult товар из JSON, который отдают методы RestHandler
+func goodResult(p graphql.ResolveParams, payload json.RawMessage, err error) (any, error) {
+	if err != nil {
+		return nil, graphQLError(p.Context, err)
+	}
+	good := database.Good{}
+	err = json.Unmarshal(payload, &good)
+	if err != nil {
+		return nil, graphQLError(p.Context, err)
+	}
+	return good, nil
+}
+
+// withArgs объединяем аргументы полей
+func withArgs(args ...graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	out := graphql.FieldConfigArgument{}
+	for _, a := range args {
+		for name, arg := range a {
+			out[name] = arg
+		}
+	}
+	return out
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// TestCheckLimits сложность считается по limit, который получит резолвер: из запроса,
+// переменной, значения переменной по умолчанию или 10, если limit не передан
+func TestCheckLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		operation string
+		variables map[string]any
+		maxDepth  int
+		want      error
+	}{
+		{name: "default limit", query: `{ goods { goods { id name } } }`},
+		{name: "literal limit", query: `{ goods(limit: 30) { goods { id name } } }`},
+		{name: "literal limit too big", query: `{ goods(limit: 100) { goods { id name } } }`, want: errQueryTooComplex},
+		{name: "non-positive limit costs the default", query: `{ goods(limit: 0) { goods { id name } } }`},
+		{name: "variable", query: `query Q($l: Int) { goods(limit: $l) { goods { id } } }`, variables: map[string]any{"l": float64(45)}},
+		{name: "variable too big", query: `query Q($l: Int) { goods(limit: $l) { goods { id } } }`, variables: map[string]any{"l": float64(100000)}, want: errQueryTooComplex},
+		{name: "missing variable costs the default", query: `query Q($l: Int) { goods(limit: $l) { goods { id } } }`},
+		{name: "variable default", query: `query Q($l: Int = 100000) { goods(limit: $l) { goods { id } } }`, want: errQueryTooComplex},
+		{name: "variable overrides its default", query: `query Q($l: Int = 100000) { goods(limit: $l) { goods { id } } }`, variables: map[string]any{"l": float64(5)}},
+		{name: "projects without limit", query: `{ projects { goods { goods { id } } } }`, want: errQueryTooComplex},
+		{name: "projects with limit", query: `{ projects(limit: 2) { goods(limit: 5) { goods { id name } } } }`},
+		{name: "fragment", query: `{ goods(limit: 45) { ...page } } fragment page on GoodsPage { goods { id } }`},
+		{name: "fragment too big", query: `{ goods(limit: 45) { ...page } } fragment page on GoodsPage { goods { id name } }`, want: errQueryTooComplex},
+		{name: "limit inside fragment", query: `query Q($l: Int = 500) { ...root } fragment root on Query { goods(limit: $l) { goods { id } } }`, want: errQueryTooComplex},
+		{name: "history", query: `{ good(projectId: 1, id: 1) { history(limit: 20) { event name } } }`},
+		{name: "history too long", query: `{ good(projectId: 1, id: 1) { history(limit: 50) { event name } } }`, want: errQueryTooComplex},
+		{name: "history of every good on a page", query: `{ goods(limit: 5) { goods { history { event } } } }`},
+		{name: "history of every good on a big page", query: `{ goods(limit: 10) { goods { history { event } } } }`, want: errQueryTooComplex},
+		{name: "project history by variable", query: `query Q($l: Int = 200) { project(id: 1) { history(limit: $l) { event } } }`, want: errQueryTooComplex},
+		{name: "deepest query", query: `{ projects(limit: 1) { goods(limit: 1) { goods { id } meta { total } } } }`},
+		{name: "too deep", query: `{ projects(limit: 1) { goods(limit: 1) { goods { id } } } }`, maxDepth: 3, want: errQueryTooDeep},
+		{name: "selected operation", query: `query A { goods(limit: 1000) { goods { id } } } query B { goods(limit: 1) { goods { id } } }`, operation: "B"},
+		{name: "other operation", query: `query A { goods(limit: 1000) { goods { id } } } query B { goods(limit: 1) { goods { id } } }`, operation: "A", want: errQueryTooComplex},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(tt.query)})})
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			g := GraphQL{MaxDepth: 4, MaxComplexity: 100}
+			if tt.maxDepth > 0 {
+				g.MaxDepth = tt.maxDepth
+			}
+			err = g.checkLimits(doc, tt.operation, tt.variables, "en")
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("checkLimits() = %v, want nil", err)
+				}
+				return
+			}
+			var got gqlError
+			if !errors.As(err, &got) || got.e.Key != tt.want.(*Error).Key {
+				t.Errorf("checkLimits() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"log"
+	"main/database"
+	natsLog "main/nats"
+
+	"github.com/nats-io/nats.go"
+)
+
+// historyQueue группа подписки NATS: событие пишет в историю только один экземпляр сервиса
+const historyQueue = "history"
+
+// SubscribeHistory пишем события из лога NATS в историю товаров
+func (rh RestHandler) SubscribeHistory() (*nats.Subscription, error) {
+	return natsLog.QueueSubscribe(rh.Nats, historyQueue, func(msgs []natsLog.LogMessage) {
+		err := database.SaveHistory(rh.DataBase, msgs)
+		if err != nil {
+			log.Print(err)
+		}
+	})
+}
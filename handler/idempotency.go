@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"main/database"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// IdempotencyConfig конфиг для ключей идемпотентности
+type IdempotencyConfig struct {
+	// TTL сколько хранить ответ под ключом
+	TTL time.Duration `yaml:"ttl"`
+	// LockTTL сколько ключ остается занятым выполняющимся запросом. Если экземпляр упадет,
+	// не сохранив ответ, повторить запрос можно будет через LockTTL
+	LockTTL time.Duration `yaml:"lockTtl"`
+}
+
+// Idempotency повторяет сохраненный ответ на запросы с тем же заголовком Idempotency-Key
+type Idempotency struct {
+	Redis   *redis.Client
+	TTL     time.Duration
+	LockTTL time.Duration
+}
+
+// NewIdempotency получаем обработчик ключей идемпотентности, по умолчанию ответ хранится сутки,
+// а ключ занят выполняющимся запросом не дольше 30 секунд
+func NewIdempotency(rdb *redis.Client, cfg IdempotencyConfig) Idempotency {
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	lockTTL := cfg.LockTTL
+	if lockTTL <= 0 {
+		lockTTL = 30 * time.Second
+	}
+	return Idempotency{
+		Redis:   rdb,
+		TTL:     ttl,
+		LockTTL: lockTTL,
+	}
+}
+
+// Wrap оборачиваем изменяющий обработчик. Запрос без Idempotency-Key выполняется как обычно.
+// Повтор с тем же ключом и тем же запросом получает сохраненный ответ, с другим запросом - 422,
+// а пока первый запрос еще выполняется - 409. Ответы 5xx и паника не сохраняются, ключ освобождается
+// и запрос можно повторить
+func (i Idempotency) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, r, validationError(err))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := requestFingerprint(r, body)
+
+		stored, reserved, err := database.ReserveIdempotencyKey(i.Redis, key, fingerprint, i.LockTTL)
+		if err != nil {
+			// без redis выполняем запрос как обычно
+			log.Print(err)
+			next(w, r)
+			return
+		}
+		if !reserved {
+			replay(w, r, stored, fingerprint)
+			return
+		}
+
+		defer func() {
+			if p := recover(); p != nil {
+				if err := database.ReleaseIdempotencyKey(i.Redis, key); err != nil {
+					log.Print(err)
+				}
+				panic(p)
+			}
+		}()
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		if rec.status >= http.StatusInternalServerError {
+			err = database.ReleaseIdempotencyKey(i.Redis, key)
+		} else {
+			err = database.SaveIdempotentResponse(i.Redis, key, database.StoredResponse{
+				Fingerprint: fingerprint,
+				Status:      rec.status,
+				Header:      w.Header().Clone(),
+				Body:        rec.body.Bytes(),
+			}, i.TTL)
+		}
+		if err != nil {
+			log.Print(err)
+		}
+	}
+}
+
+// replay отвечаем на повтор запроса
+func replay(w http.ResponseWriter, r *http.Request, stored database.StoredResponse, fingerprint string) {
+	if stored.Fingerprint != fingerprint {
+		writeError(w, r, &Error{Status: http.StatusUnprocessableEntity, Code: CodeConflict, Key: "errors.idempotency.keyReused", Message: "Idempotency-Key was already used for a different request"})
+		return
+	}
+	if !stored.Done {
+		writeError(w, r, &Error{Status: http.StatusConflict, Code: CodeConflict, Key: "errors.idempotency.inProgress", Message: "request with this Idempotency-Key is still in progress"})
+		return
+	}
+	for k, v := range stored.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
+
+// requestFingerprint отпечаток запроса: метод, путь с параметрами и тело
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder пишет ответ клиенту и запоминает его
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader запоминаем статус
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Write запоминаем тело
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
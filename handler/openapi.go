@@ -0,0 +1,30 @@
+package handler
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec описание API в формате OpenAPI 3
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// docsPage страница Redoc, которая показывает openAPISpec
+//
+//go:embed docs.html
+var docsPage []byte
+
+// OpenAPIHandler отдаем описание API
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(openAPISpec)
+}
+
+// DocsHandler отдаем страницу с документацией API
+func DocsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(200)
+	w.Write(docsPage)
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestOpenAPICoversRoutes каждый зарегистрированный путь с его методом описан в openapi.json,
+// старые пути помечены устаревшими
+func TestOpenAPICoversRoutes(t *testing.T) {
+	spec := struct {
+		Paths map[string]map[string]struct {
+			Deprecated bool `json:"deprecated"`
+		} `json:"paths"`
+	}{}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("openapi.json: %v", err)
+	}
+
+	for _, route := range Routes(RestHandler{}, Idempotency{}, GraphQL{}, NewFeed()) {
+		methods, ok := spec.Paths[route.Path]
+		if !ok {
+			t.Errorf("%s %s: path is missing from openapi.json", route.Method, route.Path)
+			continue
+		}
+		op, ok := methods[strings.ToLower(route.Method)]
+		if !ok {
+			t.Errorf("%s %s: method is missing from openapi.json", route.Method, route.Path)
+			continue
+		}
+		if op.Deprecated != route.Deprecated {
+			t.Errorf("%s %s: deprecated is %v in openapi.json, want %v", route.Method, route.Path, op.Deprecated, route.Deprecated)
+		}
+	}
+}
+
+// TestOpenAPIRefs все ссылки $ref указывают на существующие компоненты
+func TestOpenAPIRefs(t *testing.T) {
+	spec := map[string]any{}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("openapi.json: %v", err)
+	}
+
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok && !hasComponent(spec, ref) {
+				t.Errorf("unresolved $ref %s", ref)
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(spec)
+}
+
+// hasComponent есть ли в спецификации объект по ссылке вида #/components/schemas/Good
+func hasComponent(spec map[string]any, ref string) bool {
+	var node any = spec
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return false
+		}
+		if node, ok = m[part]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"main/database"
+	"net/http"
+	"strconv"
+)
+
+// ProjectBody тело входящего запроса для проекта
+type ProjectBody struct {
+	Name string `json:"name"`
+}
+
+// ProjectsHandler обработчик get-запроса списка проектов
+func (rh RestHandler) ProjectsHandler(w http.ResponseWriter, r *http.Request) {
+	payload, err := database.FindProjects(rh.DataBase)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(200)
+	w.Write(payload)
+}
+
+// ProjectPostHandler обработчик создания проекта
+func (rh RestHandler) ProjectPostHandler(w http.ResponseWriter, r *http.Request) {
+	jsonBody, err := readProjectBody(r.Body)
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+	if jsonBody.Name == "" {
+		writeError(w, r, validationError(errNameNotProvided))
+		return
+	}
+
+	payload, err := database.InsertProject(rh.DataBase, jsonBody.Name)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(200)
+	w.Write(payload)
+}
+
+// ProjectUpdateHandler обработчик переименования проекта
+func (rh RestHandler) ProjectUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	ID, err := getProjectID(param(r, "id"))
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+
+	jsonBody, err := readProjectBody(r.Body)
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+	if jsonBody.Name == "" {
+		writeError(w, r, validationError(errNameNotProvided))
+		return
+	}
+
+	payload, err := database.RenameProject(rh.DataBase, ID, jsonBody.Name)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(200)
+	w.Write(payload)
+}
+
+// ProjectDeleteHandler обработчик удаления проекта
+func (rh RestHandler) ProjectDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	ID, err := getProjectID(param(r, "id"))
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+
+	payload, err := database.DeleteProject(rh.DataBase, ID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(200)
+	w.Write(payload)
+}
+
+// getProjectID получаем id проекта
+func getProjectID(sID string) (ID int, err error) {
+	if sID == "" {
+		return 0, errIDNotProvided
+	}
+	return strconv.Atoi(sID)
+}
+
+// readProjectBody читаем тело запроса для проекта
+func readProjectBody(in io.ReadCloser) (jsonBody ProjectBody, err error) {
+	body, err := io.ReadAll(in)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(body, &jsonBody)
+	return
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"log"
+	"main/database"
+	natsLog "main/nats"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// PurgeHandler обработчик окончательного удаления помеченных удаленными товаров проекта
+func (rh RestHandler) PurgeHandler(w http.ResponseWriter, r *http.Request) {
+	spID := param(r, "projectId")
+	if spID == "" {
+		writeError(w, r, validationError(errProjectIDNotProvided))
+		return
+	}
+	pID, err := strconv.Atoi(spID)
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+
+	payload, logPayload, err := database.PurgeProject(rh.DataBase, pID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	rh.afterPurge(logPayload)
+	w.WriteHeader(200)
+	w.Write(payload)
+}
+
+// RunPurger периодически удаляем товары, которые помечены удаленными дольше срока хранения.
+// Блокирует вызывающего, запускать в отдельной горутине
+func (rh RestHandler) RunPurger(cfg database.RetentionConfig) {
+	if cfg.RemovedDays <= 0 {
+		return
+	}
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	retention := time.Duration(cfg.RemovedDays) * 24 * time.Hour
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		rh.purgeExpired(retention)
+		<-ticker.C
+	}
+}
+
+// purgeExpired удаляем товары, которые помечены удаленными дольше retention
+func (rh RestHandler) purgeExpired(retention time.Duration) {
+	// при ошибке в одном проекте уже очищенные остальные все равно сбрасываются из кеша и пишутся в лог
+	_, logPayload, err := database.PurgeExpired(rh.DataBase, time.Now().Add(-retention))
+	if err != nil {
+		log.Print(err)
+	}
+	if len(logPayload) > 0 {
+		log.Printf("purged %d removed goods", len(logPayload))
+	}
+	rh.afterPurge(logPayload)
+}
+
+// afterPurge сбрасываем кеш и пишем в лог окончательно удаленные товары
+func (rh RestHandler) afterPurge(logPayload []natsLog.LogMessage) {
+	if len(logPayload) == 0 {
+		return
+	}
+	// удаленные товары занимали позиции, поэтому сбрасываем кеш всех товаров их проектов
+	rh.invalidateProjects(logPayload)
+	err := natsLog.SendLogBatch(rh.Nats, logPayload)
+	if err != nil {
+		log.Print(err)
+	}
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"main/database"
+	"net/http"
+	"strconv"
+)
+
+// RestoreHandler обработчик восстановления удаленного товара
+func (rh RestHandler) RestoreHandler(w http.ResponseWriter, r *http.Request) {
+	ID, pID, err := getIDAndProjectID(param(r, "id"), param(r, "projectId"))
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+
+	version, err := getIfMatch(r)
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+
+	payload, err := rh.RestoreGood(ID, pID, version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	setETag(w, payload)
+	w.WriteHeader(200)
+	w.Write(payload)
+}
+
+// BatchDeleteHandler обработчик удаления пачки товаров
+func (rh RestHandler) BatchDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	rh.setGoodsRemoved(w, r, true)
+}
+
+// BatchRestoreHandler обработчик восстановления пачки товаров
+func (rh RestHandler) BatchRestoreHandler(w http.ResponseWriter, r *http.Request) {
+	rh.setGoodsRemoved(w, r, false)
+}
+
+// setGoodsRemoved общая часть пачечного удаления и восстановления
+func (rh RestHandler) setGoodsRemoved(w http.ResponseWriter, r *http.Request, removed bool) {
+	spID := param(r, "projectId")
+	if spID == "" {
+		writeError(w, r, validationError(errProjectIDNotProvided))
+		return
+	}
+	pID, err := strconv.Atoi(spID)
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+
+	jsonBody, err := readIDsBody(r.Body)
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+
+	payload, err := rh.SetGoodsRemoved(pID, jsonBody.IDs, removed)
+	if err != nil {
+		if errors.Is(err, database.ErrBadBatch) {
+			writeError(w, r, validationError(errIDsNotProvided))
+			return
+		}
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(200)
+	w.Write(payload)
+}
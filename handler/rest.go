@@ -7,10 +7,13 @@ import (
 	"io"
 	"log"
 	"main/database"
+	"main/i18n"
 	natsLog "main/nats"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/nats-io/nats.go"
 	"github.com/redis/go-redis/v9"
@@ -21,13 +24,59 @@ type RestHandler struct {
 	DataBase *sql.DB
 	Redis    *redis.Client
 	Nats     *nats.Conn
+	Batch    BatchConfig
 }
 
-// PostBody тело входящего POST и PATCH запроса
+// BatchConfig ограничения пачечных запросов
+type BatchConfig struct {
+	// MaxSize сколько элементов можно передать в одной пачке, по умолчанию 100
+	MaxSize int `yaml:"maxSize"`
+}
+
+// defaultBatchSize сколько элементов в пачке, если MaxSize не задан
+const defaultBatchSize = 100
+
+// checkBatchSize пачка не больше Batch.MaxSize
+func (rh RestHandler) checkBatchSize(n int) error {
+	maxSize := rh.Batch.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultBatchSize
+	}
+	if n > maxSize {
+		return withDetails(errBatchTooLarge, limitDetails(n, maxSize))
+	}
+	return nil
+}
+
+// PostBody тело входящего запроса смены приоритета. Позиция передается в priority, как при создании товара,
+// newPriority - прежнее название того же поля, оставлено для совместимости
 type PostBody struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Priority    int    `json:"newPriority"`
+	Priority    int    `json:"priority"`
+	NewPriority int    `json:"newPriority"`
+	Before      int    `json:"before"`
+	After       int    `json:"after"`
+	Position    string `json:"position"`
+}
+
+// move перемещение из тела запроса. priority и newPriority с разными значениями - ошибка
+func (b PostBody) move() (database.Move, error) {
+	priority := b.Priority
+	if priority == 0 {
+		priority = b.NewPriority
+	} else if b.NewPriority != 0 && b.NewPriority != priority {
+		return database.Move{}, database.ErrBadMove
+	}
+	return database.Move{
+		Priority: priority,
+		Before:   b.Before,
+		After:    b.After,
+		Position: b.Position,
+	}, nil
+}
+
+// IDsBody тело запроса со списком id товаров
+type IDsBody struct {
+	IDs []int `json:"ids"`
 }
 
 // NewRestHandler получаем новый обработчик запросов
@@ -41,94 +90,121 @@ func NewRestHandler(db *sql.DB, rdb *redis.Client, nc *nats.Conn) RestHandler {
 
 // GetHandler обрабочик get-запроса
 func (rh RestHandler) GetHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	page, err := getPage(r.URL.Query())
+	if err != nil {
+		writeError(w, r, validationError(err))
 		return
 	}
-	limit, offset, err := getLimitAndOffset(r.URL.Query())
+	values := r.URL.Query()
+	// в /v1 проект задается путем
+	if spID := r.PathValue("projectId"); spID != "" {
+		values.Set("projectId", spID)
+	}
+	filter, err := getGoodsFilter(values)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
-		log.Print(err)
+		writeError(w, r, validationError(err))
 		return
 	}
 
-	payload, err := database.FindInCache(rh.Redis, limit, offset)
-	if payload != nil {
-		w.WriteHeader(200)
-		w.Write(payload)
+	cached, err := rh.ListGoods(filter, page)
+	if err != nil {
+		writeError(w, r, err)
 		return
-	} else {
-		log.Print(err) // продолжаем
 	}
+	writeCachedPage(w, r, cached)
+}
 
-	payload, err = database.FindGoods(rh.DataBase, limit, offset)
+// GetGoodHandler обрабочик get-запроса отдельного товара
+func (rh RestHandler) GetGoodHandler(w http.ResponseWriter, r *http.Request) {
+	ID, pID, err := getIDAndProjectID(param(r, "id"), param(r, "projectId"))
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
-		log.Print(err)
+		writeError(w, r, validationError(err))
 		return
 	}
-	// пишем кеш
-	err = database.PutInCache(rh.Redis, payload, limit, offset)
+
+	payload, err := rh.FindGood(ID, pID)
 	if err != nil {
-		log.Print(err)
+		writeError(w, r, err)
+		return
 	}
-
+	setETag(w, payload)
 	w.WriteHeader(200)
 	w.Write(payload)
 }
 
 // PostHandler обрабочик post-запроса
 func (rh RestHandler) PostHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
-	}
-
-	spID := r.URL.Query().Get("projectId")
+	spID := param(r, "projectId")
 	if spID == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("projectId not provided"))
+		writeError(w, r, validationError(errProjectIDNotProvided))
 		return
 	}
 	pID, err := strconv.Atoi(spID)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
-		log.Print(err)
+		writeError(w, r, validationError(err))
 		return
 	}
 
-	jsonBody, err := readBody(r.Body)
+	jsonBody, err := readNewGood(r.Body)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
-		log.Print(err)
+		writeError(w, r, validationError(err))
 		return
 	}
 
-	if jsonBody.Name == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("name not provided"))
-		log.Print("name not provided")
+	payload, err := rh.CreateGood(pID, jsonBody)
+	if err != nil {
+		// проект передается параметром, а не в пути, поэтому это ошибка запроса, а не 404
+		if errors.Is(err, database.ErrProjectNotFound) {
+			writeError(w, r, withStatus(err, http.StatusUnprocessableEntity))
+			return
+		}
+		writeError(w, r, err)
 		return
 	}
+	setETag(w, payload)
+	w.WriteHeader(200)
+	w.Write(payload)
+}
 
-	payload, logPayload, err := database.InsertGood(rh.DataBase, pID, jsonBody.Name)
+// BatchPostHandler обрабочик создания пачки товаров
+func (rh RestHandler) BatchPostHandler(w http.ResponseWriter, r *http.Request) {
+	spID := param(r, "projectId")
+	if spID == "" {
+		writeError(w, r, validationError(errProjectIDNotProvided))
+		return
+	}
+	pID, err := strconv.Atoi(spID)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
-		log.Print(err)
+		writeError(w, r, validationError(err))
 		return
 	}
-	err = database.InvalidateCache(rh.Redis)
+
+	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		log.Print(err)
+		writeError(w, r, validationError(err))
+		return
 	}
-	err = natsLog.SendLog(rh.Nats, logPayload)
+	goods := []database.NewGood{}
+	err = json.Unmarshal(body, &goods)
 	if err != nil {
-		log.Print(err)
+		writeError(w, r, validationError(err))
+		return
+	}
+
+	payload, err := rh.CreateGoods(pID, goods)
+	if err != nil {
+		// ошибки по каждому элементу пачки уходят в details на языке запроса
+		var batchErr *database.BatchError
+		if errors.As(err, &batchErr) {
+			writeError(w, r, withDetails(withStatus(err, http.StatusUnprocessableEntity), batchErrors(batchErr, requestLang(r))))
+			return
+		}
+		if errors.Is(err, database.ErrProjectNotFound) {
+			writeError(w, r, withStatus(err, http.StatusUnprocessableEntity))
+			return
+		}
+		writeError(w, r, err)
+		return
 	}
 	w.WriteHeader(200)
 	w.Write(payload)
@@ -136,158 +212,135 @@ func (rh RestHandler) PostHandler(w http.ResponseWriter, r *http.Request) {
 
 // DeleteHandler обрабочик delete-запроса
 func (rh RestHandler) DeleteHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	ID, pID, err := getIDAndProjectID(param(r, "id"), param(r, "projectId"))
+	if err != nil {
+		writeError(w, r, validationError(err))
 		return
 	}
 
-	values := r.URL.Query()
-	ID, pID, err := getIDAndProjectID(values.Get("id"), values.Get("projectId"))
+	version, err := getIfMatch(r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
-		log.Print(err)
+		writeError(w, r, validationError(err))
 		return
 	}
 
-	payload, logPayload, err := database.DeleteGood(rh.DataBase, ID, pID)
+	payload, err := rh.RemoveGood(ID, pID, version)
 	if err != nil {
-		if errors.Is(err, database.ErrNotFound) {
-			w.WriteHeader(http.StatusNotFound)
-			w.Write(payload)
-			return
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
-		log.Print(err)
+		writeError(w, r, err)
 		return
 	}
-	err = database.InvalidateCache(rh.Redis)
-	if err != nil {
-		log.Print(err)
-	}
-	err = natsLog.SendLog(rh.Nats, logPayload)
-	if err != nil {
-		log.Print(err)
-	}
+	setETag(w, payload)
 	w.WriteHeader(200)
 	w.Write(payload)
 }
 
 // UpdateHandler обрабочик Update-запроса
 func (rh RestHandler) UpdateHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPatch {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	ID, pID, err := getIDAndProjectID(param(r, "id"), param(r, "projectId"))
+	if err != nil {
+		writeError(w, r, validationError(err))
 		return
 	}
 
-	values := r.URL.Query()
-	ID, pID, err := getIDAndProjectID(values.Get("id"), values.Get("projectId"))
+	patch, err := readPatch(r.Body)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
-		log.Print(err)
+		writeError(w, r, validationError(err))
 		return
 	}
 
-	jsonBody, err := readBody(r.Body)
+	version, err := getIfMatch(r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
-		log.Print(err)
+		writeError(w, r, validationError(err))
 		return
 	}
 
-	if jsonBody.Name == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("name not provided"))
-		log.Print("name not provided")
+	payload, err := rh.UpdateGood(ID, pID, version, patch)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
+	setETag(w, payload)
+	w.WriteHeader(200)
+	w.Write(payload)
+}
 
-	payload, logPayload, err := database.UpdateGood(rh.DataBase, ID, pID, jsonBody.Name, jsonBody.Description)
+// ReprioritiizeHandler обрабочик Repreoritiize-запроса
+func (rh RestHandler) ReprioritiizeHandler(w http.ResponseWriter, r *http.Request) {
+	ID, pID, err := getIDAndProjectID(param(r, "id"), param(r, "projectId"))
 	if err != nil {
-		if errors.Is(err, database.ErrNotFound) {
-			w.WriteHeader(http.StatusNotFound)
-			w.Write(payload)
-			return
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
-		log.Print(err)
+		writeError(w, r, validationError(err))
 		return
 	}
-	err = database.InvalidateCache(rh.Redis)
+
+	jsonBody, err := readBody(r.Body)
 	if err != nil {
-		log.Print(err)
+		writeError(w, r, validationError(err))
+		return
 	}
-	err = natsLog.SendLog(rh.Nats, logPayload)
+
+	move, err := jsonBody.move()
 	if err != nil {
-		log.Print(err)
+		writeError(w, r, validationError(err))
+		return
+	}
+	version, err := getIfMatch(r)
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+
+	payload, err := rh.ReprioritizeGood(ID, pID, version, move)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := database.ReprioritiizeResponse{}
+	if json.Unmarshal(payload, &res) == nil && len(res.Priorities) == 1 {
+		w.Header().Set("ETag", etag(res.Priorities[0].Version))
 	}
 	w.WriteHeader(200)
 	w.Write(payload)
 }
 
-// ReprioritiizeHandler обрабочик Repreoritiize-запроса
-func (rh RestHandler) ReprioritiizeHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPatch {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+// ReorderHandler обрабочик запроса нового порядка товаров проекта
+func (rh RestHandler) ReorderHandler(w http.ResponseWriter, r *http.Request) {
+	spID := param(r, "projectId")
+	if spID == "" {
+		writeError(w, r, validationError(errProjectIDNotProvided))
 		return
 	}
-
-	values := r.URL.Query()
-	ID, pID, err := getIDAndProjectID(values.Get("id"), values.Get("projectId"))
+	pID, err := strconv.Atoi(spID)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
-		log.Print(err)
+		writeError(w, r, validationError(err))
 		return
 	}
 
-	jsonBody, err := readBody(r.Body)
+	jsonBody, err := readIDsBody(r.Body)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
-		log.Print(err)
+		writeError(w, r, validationError(err))
 		return
 	}
 
-	if jsonBody.Priority == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("newPriority not provided"))
-		log.Print("newPriority not provided")
+	payload, logPayload, err := database.ReorderGoods(rh.DataBase, pID, jsonBody.IDs)
+	if err != nil {
+		// ненайденные id уходят в details
+		writeError(w, r, withDetails(err, payload))
 		return
 	}
 
-	payload, logPayload, err := database.ReprioritiizeGood(rh.DataBase, ID, pID, jsonBody.Priority)
+	// кеш сбрасываем один раз на весь новый порядок
+	err = database.InvalidateCache(rh.Redis)
 	if err != nil {
-		if errors.Is(err, database.ErrNotFound) {
-			w.WriteHeader(http.StatusNotFound)
-			w.Write(payload)
-			return
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
 		log.Print(err)
-		return
 	}
-	err = database.InvalidateCache(rh.Redis)
+	err = database.InvalidateProjectGoodsCache(rh.Redis, pID)
 	if err != nil {
 		log.Print(err)
 	}
-
-	// пишем в лог
-	for _, msg := range logPayload {
-		out, err := json.Marshal(msg)
-		if err != nil {
-			log.Print(err)
-			continue
-		}
-		err = natsLog.SendLog(rh.Nats, out)
-		if err != nil {
-			log.Print(err)
-		}
+	// все изменения уходят в лог одним сообщением
+	err = natsLog.SendLogBatch(rh.Nats, logPayload)
+	if err != nil {
+		log.Print(err)
 	}
 	w.WriteHeader(200)
 	w.Write(payload)
@@ -315,14 +368,83 @@ func getLimitAndOffset(params url.Values) (limit, offset int, err error) {
 	return limit, offset, err
 }
 
+// getPage получаем параметры страницы, наличие cursor включает выдачу по курсору
+func getPage(params url.Values) (page database.Page, err error) {
+	page.Limit, page.Offset, err = getLimitAndOffset(params)
+	if err != nil {
+		return page, err
+	}
+	if params.Has("cursor") {
+		page.Keyset = true
+		page.Cursor = params.Get("cursor")
+		page.Offset = 0
+		if page.Limit <= 0 {
+			return page, database.ErrLimitNotPositive
+		}
+	}
+	return page, nil
+}
+
+// getGoodsFilter получаем фильтр и сортировку для списка товаров
+func getGoodsFilter(params url.Values) (filter database.GoodsFilter, err error) {
+	if spID := params.Get("projectId"); spID != "" {
+		filter.ProjectID, err = strconv.Atoi(spID)
+		if err != nil {
+			return filter, err
+		}
+	}
+	if sRemoved := params.Get("removed"); sRemoved != "" {
+		removed, err := strconv.ParseBool(sRemoved)
+		if err != nil {
+			return filter, err
+		}
+		filter.Removed = &removed
+	}
+	filter.Search = strings.TrimSpace(params.Get("search"))
+	filter.CreatedFrom, err = parseTime(params.Get("createdFrom"))
+	if err != nil {
+		return filter, err
+	}
+	filter.CreatedTo, err = parseTime(params.Get("createdTo"))
+	if err != nil {
+		return filter, err
+	}
+	filter.Sort = params.Get("sort")
+	return filter, filter.Validate()
+}
+
+// parseTime разбираем время в RFC3339 или дату вида 2006-01-02
+func parseTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t, err = time.Parse(time.DateOnly, s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
+}
+
+// param значение параметра из пути, а если в пути его нет, то из query.
+// В /v1 id и projectId передаются путем, в старых путях - параметрами
+func param(r *http.Request, name string) string {
+	if v := r.PathValue(name); v != "" {
+		return v
+	}
+	return r.URL.Query().Get(name)
+}
+
 // getIDAndProjectID получаем id и projectId
 func getIDAndProjectID(sID, spID string) (ID, pID int, err error) {
 	if sID == "" {
-		err = errors.New("id not provided")
+		err = errIDNotProvided
 		return
 	}
 	if spID == "" {
-		err = errors.New("projectId not provided")
+		err = errProjectIDNotProvided
 		return
 	}
 	pID, err = strconv.Atoi(spID)
@@ -348,3 +470,59 @@ func readBody(in io.ReadCloser) (jsonBody PostBody, err error) {
 	}
 	return
 }
+
+// readIDsBody читаем тело запроса со списком id
+func readIDsBody(in io.ReadCloser) (jsonBody IDsBody, err error) {
+	body, err := io.ReadAll(in)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(body, &jsonBody)
+	return
+}
+
+// readPatch читаем тело запроса как JSON Merge Patch, тело должно быть объектом
+func readPatch(in io.ReadCloser) (patch database.GoodPatch, err error) {
+	body, err := io.ReadAll(in)
+	if err != nil {
+		return
+	}
+	fields := map[string]json.RawMessage{}
+	err = json.Unmarshal(body, &fields)
+	if err != nil || fields == nil {
+		return patch, errPatchNotObject
+	}
+	err = json.Unmarshal(body, &patch)
+	return
+}
+
+// readNewGood читаем тело запроса создания товара
+func readNewGood(in io.ReadCloser) (jsonBody database.NewGood, err error) {
+	body, err := io.ReadAll(in)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(body, &jsonBody)
+	return
+}
+
+// batchItemError ошибка проверки одного элемента пачки
+type batchItemError struct {
+	Index   int    `json:"index"`
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+// batchErrors ошибки элементов пачки с ключами и текстами на языке lang
+func batchErrors(batchErr *database.BatchError, lang string) json.RawMessage {
+	items := make([]batchItemError, 0, len(batchErr.Items))
+	for _, item := range batchErr.Items {
+		key := ToError(item.Err).Key
+		items = append(items, batchItemError{Index: item.Index, Key: key, Message: i18n.Message(lang, key)})
+	}
+	out, err := json.Marshal(map[string]any{"items": items})
+	if err != nil {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"main/database"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestBatchPostHandlerRejects неверные элементы и слишком большая пачка отклоняются до записи в БД
+func TestBatchPostHandlerRejects(t *testing.T) {
+	rh := RestHandler{Batch: BatchConfig{MaxSize: 3}}
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		key    string
+	}{
+		{name: "invalid items", body: `[{"name": "a"}, {"name": ""}, {"name": "c", "priority": 1, "position": "top"}]`, status: http.StatusUnprocessableEntity, key: "errors.validation.badBatch"},
+		{name: "too large", body: `[{"name": "a"}, {"name": "b"}, {"name": "c"}, {"name": "d"}]`, status: http.StatusBadRequest, key: "errors.validation.batchTooLarge"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/good/create/batch?projectId=1", strings.NewReader(tt.body))
+			r.Header.Set("Accept-Language", "ru")
+			w := httptest.NewRecorder()
+			rh.BatchPostHandler(w, r)
+
+			res := struct {
+				Key     string `json:"key"`
+				Details struct {
+					Items []batchItemError `json:"items"`
+					Value int              `json:"value"`
+					Max   int              `json:"max"`
+				} `json:"details"`
+			}{}
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatalf("body %s: %v", w.Body, err)
+			}
+			if w.Code != tt.status || res.Key != tt.key {
+				t.Fatalf("got %d %s, want %d %s", w.Code, res.Key, tt.status, tt.key)
+			}
+			if tt.status == http.StatusBadRequest {
+				if res.Details.Value != 4 || res.Details.Max != 3 {
+					t.Errorf("details = %+v, want value 4 and max 3", res.Details)
+				}
+				return
+			}
+			want := []batchItemError{
+				{Index: 1, Key: "errors.validation.nameRequired", Message: "не передано название"},
+				{Index: 2, Key: "errors.validation.priorityOrPosition", Message: "можно передать только priority или только position"},
+			}
+			if len(res.Details.Items) != len(want) {
+				t.Fatalf("items = %+v, want %+v", res.Details.Items, want)
+			}
+			for i := range want {
+				if res.Details.Items[i] != want[i] {
+					t.Errorf("item %d = %+v, want %+v", i, res.Details.Items[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestPostBodyMove позиция передается в priority, как при создании, прежнее newPriority тоже принимается
+func TestPostBodyMove(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		priority int
+		err      error
+	}{
+		{name: "priority", body: `{"priority": 3}`, priority: 3},
+		{name: "legacy newPriority", body: `{"newPriority": 4}`, priority: 4},
+		{name: "same value in both", body: `{"priority": 2, "newPriority": 2}`, priority: 2},
+		{name: "different values", body: `{"priority": 2, "newPriority": 5}`, err: database.ErrBadMove},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := readBody(io.NopCloser(strings.NewReader(tt.body)))
+			if err != nil {
+				t.Fatalf("readBody: %v", err)
+			}
+			move, err := body.move()
+			if !errors.Is(err, tt.err) || move.Priority != tt.priority {
+				t.Errorf("move() = %+v, %v, want priority %d, %v", move, err, tt.priority, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Route путь, метод и обработчик. Deprecated - старый путь, который оставлен для совместимости
+type Route struct {
+	Method     string
+	Path       string
+	Handler    http.HandlerFunc
+	Deprecated bool
+}
+
+// Routes все пути сервиса. Изменяющие запросы обернуты в idem, каждый путь должен быть
+// описан в openapi.json, это проверяет тест
+func Routes(rh RestHandler, idem Idempotency, gql GraphQL, feed *Feed) []Route {
+	return []Route{
+		{Method: http.MethodGet, Path: "/v1/goods", Handler: rh.GetHandler},
+		{Method: http.MethodGet, Path: "/v1/projects/{projectId}/goods", Handler: rh.GetHandler},
+		{Method: http.MethodPost, Path: "/v1/projects/{projectId}/goods", Handler: idem.Wrap(rh.PostHandler)},
+		{Method: http.MethodPost, Path: "/v1/projects/{projectId}/goods/batch", Handler: idem.Wrap(rh.BatchPostHandler)},
+		{Method: http.MethodPost, Path: "/v1/projects/{projectId}/goods/batch/remove", Handler: idem.Wrap(rh.BatchDeleteHandler)},
+		{Method: http.MethodPost, Path: "/v1/projects/{projectId}/goods/batch/restore", Handler: idem.Wrap(rh.BatchRestoreHandler)},
+		{Method: http.MethodPut, Path: "/v1/projects/{projectId}/goods/order", Handler: idem.Wrap(rh.ReorderHandler)},
+		{Method: http.MethodGet, Path: "/v1/projects/{projectId}/goods/{id}", Handler: rh.GetGoodHandler},
+		{Method: http.MethodPatch, Path: "/v1/projects/{projectId}/goods/{id}", Handler: idem.Wrap(rh.UpdateHandler)},
+		{Method: http.MethodDelete, Path: "/v1/projects/{projectId}/goods/{id}", Handler: idem.Wrap(rh.DeleteHandler)},
+		{Method: http.MethodPost, Path: "/v1/projects/{projectId}/goods/{id}/restore", Handler: idem.Wrap(rh.RestoreHandler)},
+		{Method: http.MethodPatch, Path: "/v1/projects/{projectId}/goods/{id}/priority", Handler: idem.Wrap(rh.ReprioritiizeHandler)},
+		{Method: http.MethodGet, Path: "/v1/projects", Handler: rh.ProjectsHandler},
+		{Method: http.MethodPost, Path: "/v1/projects", Handler: idem.Wrap(rh.ProjectPostHandler)},
+		{Method: http.MethodPatch, Path: "/v1/projects/{id}", Handler: idem.Wrap(rh.ProjectUpdateHandler)},
+		{Method: http.MethodDelete, Path: "/v1/projects/{id}", Handler: idem.Wrap(rh.ProjectDeleteHandler)},
+		{Method: http.MethodGet, Path: "/v1/admin/priorities", Handler: rh.PrioritiesHandler},
+		{Method: http.MethodPost, Path: "/v1/admin/priorities/compact", Handler: idem.Wrap(rh.CompactPrioritiesHandler)},
+		{Method: http.MethodPost, Path: "/v1/admin/projects/{projectId}/purge", Handler: idem.Wrap(rh.PurgeHandler)},
+		{Method: http.MethodGet, Path: "/v1/goods/events", Handler: feed.Handler},
+		{Method: http.MethodGet, Path: "/v1/projects/{projectId}/goods/events", Handler: feed.Handler},
+		{Method: http.MethodGet, Path: "/v1/projects/{projectId}/webhooks", Handler: rh.WebhooksHandler},
+		{Method: http.MethodPost, Path: "/v1/projects/{projectId}/webhooks", Handler: idem.Wrap(rh.WebhookPostHandler)},
+		{Method: http.MethodPatch, Path: "/v1/projects/{projectId}/webhooks/{id}", Handler: idem.Wrap(rh.WebhookUpdateHandler)},
+		{Method: http.MethodDelete, Path: "/v1/projects/{projectId}/webhooks/{id}", Handler: idem.Wrap(rh.WebhookDeleteHandler)},
+		{Method: http.MethodGet, Path: "/v1/projects/{projectId}/webhooks/{id}/deliveries", Handler: rh.DeliveriesHandler},
+		{Method: http.MethodPost, Path: "/v1/projects/{projectId}/webhooks/{id}/deliveries/{deliveryId}/replay", Handler: idem.Wrap(rh.ReplayHandler)},
+
+		legacy(http.MethodGet, "/good", rh.GetHandler),
+		legacy(http.MethodGet, "/good/{projectId}/{id}", rh.GetGoodHandler),
+		legacy(http.MethodPost, "/good/create", idem.Wrap(rh.PostHandler)),
+		legacy(http.MethodPost, "/good/create/batch", idem.Wrap(rh.BatchPostHandler)),
+		legacy(http.MethodDelete, "/good/remove", idem.Wrap(rh.DeleteHandler)),
+		legacy(http.MethodDelete, "/good/remove/batch", idem.Wrap(rh.BatchDeleteHandler)),
+		legacy(http.MethodPatch, "/good/restore", idem.Wrap(rh.RestoreHandler)),
+		legacy(http.MethodPatch, "/good/restore/batch", idem.Wrap(rh.BatchRestoreHandler)),
+		legacy(http.MethodPatch, "/good/update", idem.Wrap(rh.UpdateHandler)),
+		legacy(http.MethodPatch, "/good/reprioritiize", idem.Wrap(rh.ReprioritiizeHandler)),
+		legacy(http.MethodPatch, "/good/reorder", idem.Wrap(rh.ReorderHandler)),
+		legacy(http.MethodGet, "/project", rh.ProjectsHandler),
+		legacy(http.MethodPost, "/project/create", idem.Wrap(rh.ProjectPostHandler)),
+		legacy(http.MethodPatch, "/project/update", idem.Wrap(rh.ProjectUpdateHandler)),
+		legacy(http.MethodDelete, "/project/remove", idem.Wrap(rh.ProjectDeleteHandler)),
+		legacy(http.MethodGet, "/admin/priorities", rh.PrioritiesHandler),
+		legacy(http.MethodPost, "/admin/priorities/compact", idem.Wrap(rh.CompactPrioritiesHandler)),
+		legacy(http.MethodDelete, "/admin/purge", idem.Wrap(rh.PurgeHandler)),
+
+		{Method: http.MethodPost, Path: "/graphql", Handler: idem.Wrap(gql.Handler)},
+
+		{Method: http.MethodGet, Path: "/openapi.json", Handler: OpenAPIHandler},
+		{Method: http.MethodGet, Path: "/docs", Handler: DocsHandler},
+	}
+}
+
+// legacyDeprecatedAt когда старые пути объявлены устаревшими (появился /v1), legacySunset - когда их уберут
+var (
+	legacyDeprecatedAt = time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+	legacySunset       = legacyDeprecatedAt.AddDate(0, 6, 0)
+)
+
+// legacy старый путь без версии, ответы на него помечаются заголовками Deprecation (RFC 9745)
+// и Sunset (RFC 8594), а Link ведет на документацию с новыми путями
+func legacy(method, path string, next http.HandlerFunc) Route {
+	deprecation := "@" + strconv.FormatInt(legacyDeprecatedAt.Unix(), 10)
+	sunset := legacySunset.Format(http.TimeFormat)
+	return Route{
+		Method: method,
+		Path:   path,
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", deprecation)
+			w.Header().Set("Sunset", sunset)
+			w.Header().Set("Link", `</docs>; rel="deprecation"; type="text/html"`)
+			next(w, r)
+		},
+		Deprecated: true,
+	}
+}
+
+// errRouteNotFound ответ на запрос по неизвестному пути
+var errRouteNotFound = &Error{Status: http.StatusNotFound, Code: CodeNotFound, Key: "errors.common.routeNotFound", Message: "route not found"}
+
+// Router маршрутизатор по таблице путей. Сначала по одному пути ищется самый подходящий шаблон
+// без учета метода, и только потом проверяется метод. Так запрос к /good/remove/batch с чужим методом
+// получает 405, а не попадает в /good/{projectId}/{id}. 404 и 405 с заголовком Allow отдаются в формате Error
+type Router struct {
+	mux *http.ServeMux
+	// paths шаблоны путей без методов, methods - методы каждого шаблона
+	paths   *http.ServeMux
+	methods map[string][]string
+}
+
+// NewRouter получаем маршрутизатор для путей routes
+func NewRouter(routes []Route) Router {
+	rt := Router{mux: http.NewServeMux(), paths: http.NewServeMux(), methods: map[string][]string{}}
+	for _, route := range routes {
+		rt.mux.HandleFunc(route.Method+" "+route.Path, route.Handler)
+		if _, ok := rt.methods[route.Path]; !ok {
+			rt.paths.HandleFunc(route.Path, http.NotFound)
+		}
+		rt.methods[route.Path] = append(rt.methods[route.Path], route.Method)
+	}
+	return rt
+}
+
+// ServeHTTP обрабатываем запрос
+func (rt Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_, path := rt.paths.Handler(r)
+	methods, ok := rt.methods[path]
+	if !ok {
+		writeError(w, r, errRouteNotFound)
+		return
+	}
+	for _, method := range methods {
+		if r.Method == method || (r.Method == http.MethodHead && method == http.MethodGet) {
+			rt.mux.ServeHTTP(w, r)
+			return
+		}
+	}
+	w.Header().Set("Allow", allowHeader(methods))
+	writeError(w, r, errMethodNotAllowed)
+}
+
+// allowHeader значение Allow для методов шаблона, вместе с GET разрешен и HEAD
+func allowHeader(methods []string) string {
+	allow := append([]string{}, methods...)
+	if slices.Contains(allow, http.MethodGet) {
+		allow = append(allow, http.MethodHead)
+	}
+	slices.Sort(allow)
+	return strings.Join(allow, ", ")
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRouterMethods литеральный путь с чужим методом получает 405 с Allow, а не уходит в шаблон с параметрами,
+// неизвестный путь - 404, оба ответа в формате Error
+func TestRouterMethods(t *testing.T) {
+	router := NewRouter(Routes(RestHandler{}, Idempotency{}, GraphQL{}, NewFeed()))
+	tests := []struct {
+		method, path string
+		status       int
+		allow        string
+		key          string
+	}{
+		{method: http.MethodGet, path: "/good/remove/batch", status: http.StatusMethodNotAllowed, allow: "DELETE", key: "errors.common.methodNotAllowed"},
+		{method: http.MethodGet, path: "/good/create/batch", status: http.StatusMethodNotAllowed, allow: "POST", key: "errors.common.methodNotAllowed"},
+		{method: http.MethodGet, path: "/good/restore", status: http.StatusMethodNotAllowed, allow: "PATCH", key: "errors.common.methodNotAllowed"},
+		{method: http.MethodGet, path: "/v1/projects/1/goods/order", status: http.StatusMethodNotAllowed, allow: "PUT", key: "errors.common.methodNotAllowed"},
+		{method: http.MethodPost, path: "/good/1/2", status: http.StatusMethodNotAllowed, allow: "GET, HEAD", key: "errors.common.methodNotAllowed"},
+		{method: http.MethodPut, path: "/v1/projects/1/goods/2", status: http.StatusMethodNotAllowed, allow: "DELETE, GET, HEAD, PATCH", key: "errors.common.methodNotAllowed"},
+		{method: http.MethodDelete, path: "/v1/admin/projects/1/purge", status: http.StatusMethodNotAllowed, allow: "POST", key: "errors.common.methodNotAllowed"},
+		{method: http.MethodGet, path: "/unknown", status: http.StatusNotFound, key: "errors.common.routeNotFound"},
+		{method: http.MethodGet, path: "/good/1/2/3", status: http.StatusNotFound, key: "errors.common.routeNotFound"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			res := struct {
+				Key string `json:"key"`
+			}{}
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatalf("body %s: %v", w.Body, err)
+			}
+			if w.Code != tt.status || res.Key != tt.key {
+				t.Errorf("got %d %s, want %d %s", w.Code, res.Key, tt.status, tt.key)
+			}
+			if allow := w.Header().Get("Allow"); allow != tt.allow {
+				t.Errorf("Allow = %q, want %q", allow, tt.allow)
+			}
+		})
+	}
+}
+
+// TestRouterServes подходящий путь и метод доходят до обработчика, HEAD обслуживается как GET,
+// старые пути отвечают с заголовками Deprecation, Sunset и Link
+func TestRouterServes(t *testing.T) {
+	served := ""
+	handle := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			served = name
+		}
+	}
+	router := NewRouter([]Route{
+		{Method: http.MethodGet, Path: "/v1/projects/{projectId}/goods/{id}", Handler: handle("good")},
+		{Method: http.MethodPut, Path: "/v1/projects/{projectId}/goods/order", Handler: handle("order")},
+		legacy(http.MethodGet, "/good/{projectId}/{id}", handle("legacy good")),
+		legacy(http.MethodDelete, "/good/remove/batch", handle("legacy batch")),
+	})
+	tests := []struct {
+		method, path string
+		served       string
+		legacy       bool
+	}{
+		{method: http.MethodGet, path: "/v1/projects/1/goods/2", served: "good"},
+		{method: http.MethodHead, path: "/v1/projects/1/goods/2", served: "good"},
+		{method: http.MethodPut, path: "/v1/projects/1/goods/order", served: "order"},
+		{method: http.MethodGet, path: "/good/1/2", served: "legacy good", legacy: true},
+		{method: http.MethodDelete, path: "/good/remove/batch", served: "legacy batch", legacy: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			served = ""
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if served != tt.served {
+				t.Fatalf("served by %q, want %q", served, tt.served)
+			}
+			if !tt.legacy {
+				if h := w.Header().Get("Deprecation"); h != "" {
+					t.Errorf("Deprecation = %q on a current path", h)
+				}
+				return
+			}
+			if h := w.Header().Get("Deprecation"); h != "@1792195200" {
+				t.Errorf("Deprecation = %q, want @1792195200", h)
+			}
+			sunset, err := http.ParseTime(w.Header().Get("Sunset"))
+			if err != nil || !sunset.Equal(time.Date(2027, time.April, 17, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("Sunset = %q, want 2027-04-17", w.Header().Get("Sunset"))
+			}
+			if h := w.Header().Get("Link"); h != `</docs>; rel="deprecation"; type="text/html"` {
+				t.Errorf("Link = %q", h)
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"main/database"
+	natsLog "main/nats"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// WebhooksConfig настройки доставки событий подпискам
+type WebhooksConfig struct {
+	// Interval как часто искать доставки, которым пора уходить
+	Interval time.Duration `yaml:"interval"`
+	// Batch сколько доставок забирать за раз, они отправляются параллельно
+	Batch int `yaml:"batch"`
+	// Timeout сколько ждать ответа получателя
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxAttempts после стольких неудачных попыток доставка помечается failed
+	MaxAttempts int `yaml:"maxAttempts"`
+	// BaseDelay задержка перед второй попыткой, дальше она удваивается до MaxDelay
+	BaseDelay time.Duration `yaml:"baseDelay"`
+	MaxDelay  time.Duration `yaml:"maxDelay"`
+}
+
+// withDefaults заполняем незаданные настройки
+func (cfg WebhooksConfig) withDefaults() WebhooksConfig {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	if cfg.Batch <= 0 {
+		cfg.Batch = 20
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 8
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = 10 * time.Second
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = time.Hour
+	}
+	return cfg
+}
+
+// заголовки запроса к получателю
+const (
+	headerWebhookEvent     = "X-Webhook-Event"
+	headerWebhookDelivery  = "X-Webhook-Delivery"
+	headerWebhookTimestamp = "X-Webhook-Timestamp"
+	headerWebhookSignature = "X-Webhook-Signature"
+)
+
+// webhooksQueue группа подписки NATS: событие ставит в очередь только один экземпляр сервиса
+const webhooksQueue = "webhooks"
+
+// deliveriesLimit сколько записей журнала доставок отдавать по умолчанию
+const deliveriesLimit = 50
+
+// WebhooksHandler обработчик get-запроса подписок проекта
+func (rh RestHandler) WebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	pID, err := getProjectID(param(r, "projectId"))
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+
+	payload, err := database.FindWebhooks(rh.DataBase, pID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(200)
+	w.Write(payload)
+}
+
+// WebhookPostHandler обработчик создания подписки
+func (rh RestHandler) WebhookPostHandler(w http.ResponseWriter, r *http.Request) {
+	pID, err := getProjectID(param(r, "projectId"))
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+
+	jsonBody := database.NewWebhook{}
+	err = readJSON(r.Body, &jsonBody)
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+	err = jsonBody.Validate()
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+
+	payload, err := database.InsertWebhook(rh.DataBase, pID, jsonBody)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(200)
+	w.Write(payload)
+}
+
+// WebhookUpdateHandler обработчик изменения подписки
+func (rh RestHandler) WebhookUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	ID, pID, err := getIDAndProjectID(param(r, "id"), param(r, "projectId"))
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+
+	patch := database.WebhookPatch{}
+	err = readJSON(r.Body, &patch)
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+	err = patch.Validate()
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+
+	payload, err := database.UpdateWebhook(rh.DataBase, ID, pID, patch)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(200)
+	w.Write(payload)
+}
+
+// WebhookDeleteHandler обработчик удаления подписки
+func (rh RestHandler) WebhookDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	ID, pID, err := getIDAndProjectID(param(r, "id"), param(r, "projectId"))
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+
+	payload, err := database.DeleteWebhook(rh.DataBase, ID, pID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(200)
+	w.Write(payload)
+}
+
+// DeliveriesHandler обработчик журнала доставок подписки
+func (rh RestHandler) DeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	ID, pID, err := getIDAndProjectID(param(r, "id"), param(r, "projectId"))
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+	limit := deliveriesLimit
+	if sLimit := r.URL.Query().Get("limit"); sLimit != "" {
+		limit, err = strconv.Atoi(sLimit)
+		if err != nil {
+			writeError(w, r, validationError(err))
+			return
+		}
+		if limit <= 0 {
+			writeError(w, r, validationError(database.ErrLimitNotPositive))
+			return
+		}
+	}
+
+	payload, err := database.FindDeliveries(rh.DataBase, ID, pID, limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(200)
+	w.Write(payload)
+}
+
+// ReplayHandler обработчик повторной отправки доставки
+func (rh RestHandler) ReplayHandler(w http.ResponseWriter, r *http.Request) {
+	ID, pID, err := getIDAndProjectID(param(r, "id"), param(r, "projectId"))
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+	deliveryID, err := strconv.ParseInt(param(r, "deliveryId"), 10, 64)
+	if err != nil {
+		writeError(w, r, validationError(err))
+		return
+	}
+
+	payload, err := database.ReplayDelivery(rh.DataBase, deliveryID, ID, pID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(200)
+	w.Write(payload)
+}
+
+// SubscribeWebhooks ставим события из лога NATS в очередь доставки подпискам
+func (rh RestHandler) SubscribeWebhooks() (*nats.Subscription, error) {
+	return natsLog.QueueSubscribe(rh.Nats, webhooksQueue, func(msgs []natsLog.LogMessage) {
+		_, err := database.EnqueueDeliveries(rh.DataBase, msgs)
+		if err != nil {
+			log.Print(err)
+		}
+	})
+}
+
+// RunWebhooks отправляем доставки, которым пора уходить. Экземпляры сервиса забирают
+// доставки из общей очереди в Postgres, каждую отправляет один из них.
+// Блокирует вызывающего, запускать в отдельной горутине
+func (rh RestHandler) RunWebhooks(cfg WebhooksConfig) {
+	cfg = cfg.withDefaults()
+	client := &http.Client{Timeout: cfg.Timeout}
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		rh.sendDue(client, cfg)
+		<-ticker.C
+	}
+}
+
+// sendDue отправляем одну пачку доставок
+func (rh RestHandler) sendDue(client *http.Client, cfg WebhooksConfig) {
+	// пока попытка идет, доставку не заберет другой экземпляр
+	lease := cfg.Timeout * 2
+	deliveries, err := database.ClaimDeliveries(rh.DataBase, cfg.Batch, lease)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	wg := sync.WaitGroup{}
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := deliver(client, cfg, delivery, time.Now())
+			err := database.SaveDeliveryResult(rh.DataBase, delivery.ID, res)
+			if err != nil {
+				log.Print(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// deliver отправляем доставку и получаем итог попытки. Доставка выключенной подписки не отправляется
+// и закрывается как skipped
+func deliver(client *http.Client, cfg WebhooksConfig, delivery database.Delivery, now time.Time) database.DeliveryResult {
+	if !delivery.Active {
+		return database.DeliveryResult{Status: database.DeliverySkipped, Error: database.ErrWebhookInactive.Error()}
+	}
+	status, err := sendWebhook(client, delivery, now)
+	return deliveryResult(cfg, delivery.Attempts, status, err)
+}
+
+// sendWebhook отправляем событие получателю, ответ 2xx - успех
+func sendWebhook(client *http.Client, delivery database.Delivery, now time.Time) (status int, err error) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(headerWebhookEvent, delivery.Event)
+	req.Header.Set(headerWebhookDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(headerWebhookTimestamp, timestamp)
+	req.Header.Set(headerWebhookSignature, signWebhook(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// тело не нужно, но дочитываем его, чтобы соединение можно было переиспользовать
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// signWebhook подпись запроса: HMAC-SHA256 от "timestamp.тело" ключом подписки.
+// Время в подписи не дает повторить перехваченный запрос позже
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliveryResult итог попытки номер attempts: успех, повтор с задержкой или отказ после MaxAttempts
+func deliveryResult(cfg WebhooksConfig, attempts, status int, err error) database.DeliveryResult {
+	if err == nil {
+		return database.DeliveryResult{Status: database.DeliveryDelivered, ResponseStatus: status}
+	}
+	res := database.DeliveryResult{Status: database.DeliveryPending, ResponseStatus: status, Error: err.Error()}
+	if attempts >= cfg.MaxAttempts {
+		res.Status = database.DeliveryFailed
+		return res
+	}
+	res.RetryIn = retryDelay(cfg, attempts)
+	return res
+}
+
+// retryDelay задержка после неудачной попытки номер attempts: BaseDelay, затем вдвое больше, но не больше MaxDelay
+func retryDelay(cfg WebhooksConfig, attempts int) time.Duration {
+	delay := cfg.BaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= cfg.MaxDelay {
+			return cfg.MaxDelay
+		}
+	}
+	return min(delay, cfg.MaxDelay)
+}
+
+// readJSON читаем тело запроса в v
+func readJSON(in io.ReadCloser, v any) error {
+	body, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"io"
+	"main/database"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestSendWebhook получатель видит событие, тело и подпись, которую можно проверить ключом подписки
+func TestSendWebhook(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	var got *http.Request
+	var gotBody []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	delivery := database.Delivery{
+		ID:      42,
+		Event:   "update",
+		Payload: []byte(`{"id":1,"project_id":2,"event":"update"}`),
+		URL:     receiver.URL,
+		Secret:  "secret",
+	}
+	status, err := sendWebhook(receiver.Client(), delivery, now)
+	if err != nil {
+		t.Fatalf("sendWebhook: %v", err)
+	}
+	if status != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", status, http.StatusNoContent)
+	}
+	if got.Method != http.MethodPost {
+		t.Errorf("method = %s, want POST", got.Method)
+	}
+	if string(gotBody) != string(delivery.Payload) {
+		t.Errorf("body = %s, want %s", gotBody, delivery.Payload)
+	}
+	if h := got.Header.Get(headerWebhookEvent); h != "update" {
+		t.Errorf("%s = %q, want update", headerWebhookEvent, h)
+	}
+	if h := got.Header.Get(headerWebhookDelivery); h != "42" {
+		t.Errorf("%s = %q, want 42", headerWebhookDelivery, h)
+	}
+	timestamp := got.Header.Get(headerWebhookTimestamp)
+	if timestamp != "1700000000" {
+		t.Errorf("%s = %q, want 1700000000", headerWebhookTimestamp, timestamp)
+	}
+	if h := got.Header.Get(headerWebhookSignature); h != signWebhook("secret", timestamp, gotBody) {
+		t.Errorf("%s = %q does not match the body", headerWebhookSignature, h)
+	}
+	if signWebhook("other", timestamp, gotBody) == signWebhook("secret", timestamp, gotBody) {
+		t.Error("signature does not depend on the secret")
+	}
+}
+
+// TestSendWebhookFailure ответ не 2xx и недоступный получатель - ошибка доставки
+func TestSendWebhookFailure(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	delivery := database.Delivery{ID: 1, Event: "create", Payload: []byte(`{}`), URL: receiver.URL}
+	status, err := sendWebhook(receiver.Client(), delivery, time.Now())
+	if err == nil || status != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, err %v, want 503 and an error", status, err)
+	}
+
+	receiver.Close()
+	status, err = sendWebhook(http.DefaultClient, delivery, time.Now())
+	if err == nil || status != 0 {
+		t.Errorf("closed receiver: got status %d, err %v, want 0 and an error", status, err)
+	}
+}
+
+// TestDeliverInactive доставка выключенной подписки не отправляется и закрывается как skipped
+func TestDeliverInactive(t *testing.T) {
+	requests := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+	cfg := WebhooksConfig{}.withDefaults()
+	delivery := database.Delivery{ID: 1, Event: "update", Payload: []byte(`{}`), URL: receiver.URL, Secret: "secret"}
+
+	res := deliver(receiver.Client(), cfg, delivery, time.Now())
+	if res.Status != database.DeliverySkipped || requests != 0 {
+		t.Errorf("inactive: status %s after %d requests, want %s and no requests", res.Status, requests, database.DeliverySkipped)
+	}
+	if res.Error == "" || res.RetryIn != 0 {
+		t.Errorf("inactive: error %q, retry in %v, want a reason and no retry", res.Error, res.RetryIn)
+	}
+
+	delivery.Active = true
+	delivery.Attempts = 1
+	res = deliver(receiver.Client(), cfg, delivery, time.Now())
+	if res.Status != database.DeliveryDelivered || requests != 1 {
+		t.Errorf("active: status %s after %d requests, want %s and one request", res.Status, requests, database.DeliveryDelivered)
+	}
+}
+
+// TestDeliveryResult повторы с удвоением задержки до MaxDelay и отказ после MaxAttempts
+func TestDeliveryResult(t *testing.T) {
+	cfg := WebhooksConfig{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: 5 * time.Second}.withDefaults()
+
+	res := deliveryResult(cfg, 1, http.StatusOK, nil)
+	if res.Status != database.DeliveryDelivered {
+		t.Errorf("success: status = %s, want %s", res.Status, database.DeliveryDelivered)
+	}
+
+	errReceiver := io.ErrUnexpectedEOF
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	for i, delay := range want {
+		res = deliveryResult(cfg, i+1, http.StatusBadGateway, errReceiver)
+		if res.Status != database.DeliveryPending || res.RetryIn != delay {
+			t.Errorf("attempt %d: got %s in %v, want %s in %v", i+1, res.Status, res.RetryIn, database.DeliveryPending, delay)
+		}
+		if res.ResponseStatus != http.StatusBadGateway || res.Error == "" {
+			t.Errorf("attempt %d: response %d, error %q are not saved", i+1, res.ResponseStatus, res.Error)
+		}
+	}
+
+	res = deliveryResult(cfg, 5, http.StatusBadGateway, errReceiver)
+	if res.Status != database.DeliveryFailed {
+		t.Errorf("last attempt: status = %s, want %s", res.Status, database.DeliveryFailed)
+	}
+}
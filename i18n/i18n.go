@@ -0,0 +1,67 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLang язык, если Accept-Language не передан или ни один язык из него не поддерживается
+const DefaultLang = "en"
+
+// Message текст сообщения key на языке lang. Если перевода нет, берется DefaultLang,
+// а если нет и его, возвращается сам ключ
+func Message(lang, key string) string {
+	if text, ok := catalog[lang][key]; ok {
+		return text
+	}
+	if text, ok := catalog[DefaultLang][key]; ok {
+		return text
+	}
+	return key
+}
+
+// Has есть ли в каталоге текст для ключа
+func Has(key string) bool {
+	_, ok := catalog[DefaultLang][key]
+	return ok
+}
+
+// Lang выбираем поддерживаемый язык по заголовку Accept-Language (RFC 9110, 12.5.4).
+// Регион не учитывается: en-US и en-GB дают en
+func Lang(acceptLanguage string) string {
+	type option struct {
+		lang string
+		q    float64
+	}
+	options := []option{}
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if q <= 0 || lang == "" {
+			continue
+		}
+		options = append(options, option{lang: lang, q: q})
+	}
+	// при равном q сохраняется порядок из заголовка
+	sort.SliceStable(options, func(i, j int) bool {
+		return options[i].q > options[j].q
+	})
+	for _, o := range options {
+		if o.lang == "*" {
+			return DefaultLang
+		}
+		if _, ok := catalog[o.lang]; ok {
+			return o.lang
+		}
+	}
+	return DefaultLang
+}
@@ -0,0 +1,33 @@
+package i18n
+
+import "testing"
+
+// TestLang выбор языка по Accept-Language: q-значения, регион, звездочка и неподдерживаемые языки
+func TestLang(t *testing.T) {
+	tests := []struct {
+		name, header, want string
+	}{
+		{name: "empty", header: "", want: "en"},
+		{name: "single", header: "ru", want: "ru"},
+		{name: "region fallback", header: "en-US", want: "en"},
+		{name: "region fallback ru", header: "ru-RU", want: "ru"},
+		{name: "case insensitive", header: "RU-ru", want: "ru"},
+		{name: "q order", header: "en;q=0.5, ru;q=0.9", want: "ru"},
+		{name: "default q is 1", header: "en;q=0.9, ru", want: "ru"},
+		{name: "equal q keeps header order", header: "ru;q=0.8, en;q=0.8", want: "ru"},
+		{name: "q zero excluded", header: "ru;q=0, en-GB;q=0.1", want: "en"},
+		{name: "bad q skipped", header: "ru;q=abc, en;q=0.1", want: "en"},
+		{name: "unsupported skipped", header: "de, fr;q=0.9, ru;q=0.1", want: "ru"},
+		{name: "only unsupported", header: "de, fr", want: "en"},
+		{name: "star", header: "*", want: "en"},
+		{name: "star before supported", header: "*, ru;q=0.5", want: "en"},
+		{name: "supported before star", header: "de, ru;q=0.9, *;q=0.5", want: "ru"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Lang(tt.header); got != tt.want {
+				t.Errorf("Lang(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package i18n
+
+// catalog тексты сообщений по языку и ключу. Ключи отдаются клиентам как есть,
+// поэтому их не переименовываем, а только добавляем новые
+var catalog = map[string]map[string]string{
+	"en": {
+		"errors.common.notFound":           "good not found",
+		"errors.common.preconditionFailed": "good was changed, version from If-Match is outdated",
+		"errors.common.internal":           "internal error",
+		"errors.common.methodNotAllowed":   "method not allowed",
+		"errors.common.routeNotFound":      "route not found",
+
+		"errors.project.notFound": "project not found",
+		"errors.project.notEmpty": "project has goods",
+
+		"errors.good.anchorNotFound": "anchor good not found",
+		"errors.good.noRankGap":      "no room between ranks, rebalance the project and retry",
+
+		"errors.webhook.notFound":         "webhook not found",
+		"errors.webhook.deliveryNotFound": "delivery not found",
+		"errors.webhook.inactive":         "webhook is inactive, activate it to replay deliveries",
+
+		"errors.validation.badRequest":         "invalid request",
+		"errors.validation.idRequired":         "id not provided",
+		"errors.validation.projectIdRequired":  "projectId not provided",
+		"errors.validation.idsRequired":        "ids not provided",
+		"errors.validation.nameRequired":       "name not provided",
+		"errors.validation.priorityNegative":   "priority must be positive",
+		"errors.validation.positionInvalid":    "position must be top or bottom",
+		"errors.validation.priorityOrPosition": "only one of priority or position may be provided",
+		"errors.validation.limitPositive":      "limit must be positive",
+		"errors.validation.badCursor":          "bad cursor",
+		"errors.validation.badSort":            "unknown sort field",
+		"errors.validation.badMove":            "exactly one of priority, before, after or position=top|bottom must be provided",
+		"errors.validation.badReorder":         "ids must be unique and not empty",
+		"errors.validation.badBatch":           "batch has invalid items",
+		"errors.validation.batchTooLarge":      "batch is too large",
+		"errors.validation.badPatch":           "name cannot be null or empty",
+		"errors.validation.patchNotObject":     "merge patch must be a JSON object",
+		"errors.validation.badIfMatch":         "If-Match must be * or a single strong ETag",
+		"errors.validation.webhookURL":         "url must be an absolute http or https URL",
+		"errors.validation.webhookEvent":       "unknown event",
+
+		"errors.idempotency.keyReused":  "Idempotency-Key was already used for a different request",
+		"errors.idempotency.inProgress": "request with this Idempotency-Key is still in progress",
+
+		"errors.graphql.tooDeep":    "query is too deep",
+		"errors.graphql.tooComplex": "query is too complex",
+
+		"errors.db.alreadyExists": "already exists",
+		"errors.db.reference":     "referenced object is missing or still in use",
+		"errors.db.constraint":    "value violates a constraint",
+		"errors.db.invalidValue":  "invalid value",
+	},
+	"ru": {
+		"errors.common.notFound":           "товар не найден",
+		"errors.common.preconditionFailed": "товар изменился, версия из If-Match устарела",
+		"errors.common.internal":           "внутренняя ошибка",
+		"errors.common.methodNotAllowed":   "метод не поддерживается",
+		"errors.common.routeNotFound":      "путь не найден",
+
+		"errors.project.notFound": "проект не найден",
+		"errors.project.notEmpty": "в проекте есть товары",
+
+		"errors.good.anchorNotFound": "товар, относительно которого перемещаем, не найден",
+		"errors.good.noRankGap":      "между соседями нет места, перебалансируйте проект и повторите",
+
+		"errors.webhook.notFound":         "подписка не найдена",
+		"errors.webhook.deliveryNotFound": "доставка не найдена",
+		"errors.webhook.inactive":         "подписка выключена, включите ее, чтобы повторить доставку",
+
+		"errors.validation.badRequest":         "неверный запрос",
+		"errors.validation.idRequired":         "не передан id",
+		"errors.validation.projectIdRequired":  "не передан projectId",
+		"errors.validation.idsRequired":        "не передан список ids",
+		"errors.validation.nameRequired":       "не передано название",
+		"errors.validation.priorityNegative":   "приоритет должен быть положительным",
+		"errors.validation.positionInvalid":    "position должен быть top или bottom",
+		"errors.validation.priorityOrPosition": "можно передать только priority или только position",
+		"errors.validation.limitPositive":      "limit должен быть положительным",
+		"errors.validation.badCursor":          "неверный курсор",
+		"errors.validation.badSort":            "неизвестное поле сортировки",
+		"errors.validation.badMove":            "нужно передать ровно одно из priority, before, after или position=top|bottom",
+		"errors.validation.badReorder":         "ids должны быть уникальными и непустыми",
+		"errors.validation.badBatch":           "в пачке есть неверные элементы",
+		"errors.validation.batchTooLarge":      "слишком большая пачка",
+		"errors.validation.badPatch":           "название не может быть null или пустым",
+		"errors.validation.patchNotObject":     "merge patch должен быть JSON-объектом",
+		"errors.validation.badIfMatch":         "If-Match должен быть * или одним сильным ETag",
+		"errors.validation.webhookURL":         "url должен быть абсолютным адресом http или https",
+		"errors.validation.webhookEvent":       "неизвестное событие",
+
+		"errors.idempotency.keyReused":  "Idempotency-Key уже использован для другого запроса",
+		"errors.idempotency.inProgress": "запрос с этим Idempotency-Key еще выполняется",
+
+		"errors.graphql.tooDeep":    "слишком большая вложенность запроса",
+		"errors.graphql.tooComplex": "слишком сложный запрос",
+
+		"errors.db.alreadyExists": "уже существует",
+		"errors.db.reference":     "связанный объект не существует или еще используется",
+		"errors.db.constraint":    "значение нарушает ограничение",
+		"errors.db.invalidValue":  "неверное значение",
+	},
+}
@@ -8,6 +8,9 @@ name text,
 description text,
 priority integer ,
 removed bool DEFAULT false,
+purged bool DEFAULT false,
+version integer,
+event text DEFAULT '',
 event_time timestamp DEFAULT now()
 )
 ENGINE = NATS
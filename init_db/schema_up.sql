@@ -1,16 +1,17 @@
 CREATE SCHEMA IF NOT EXISTS test_issue AUTHORIZATION sample;
 
-CREATE FUNCTION add_priority()  
-RETURNS INTEGER  
+-- ранг для нового товара в конце проекта, шаг совпадает с rankStep в database/rank.go
+CREATE FUNCTION next_rank(pid integer)  
+RETURNS BIGINT  
 AS  
 $$  
-DECLARE M INTEGER;
+DECLARE M BIGINT;
 BEGIN  
-select MAX(priority) INTO M from test_issue.goods; 
+select MAX(rank) INTO M from test_issue.goods where project_id = pid; 
 IF M IS NULL THEN
 M = 0;
 END IF;
-RETURN M+1;  
+RETURN M+65536;  
 END;
 $$
 LANGUAGE plpgsql;
@@ -23,13 +24,69 @@ created_at timestamp DEFAULT now()
 
 CREATE TABLE IF NOT EXISTS test_issue.goods (
 id integer GENERATED BY DEFAULT AS IDENTITY,
-project_id integer,
+project_id integer REFERENCES test_issue.projects(id),
 name text,
 description text,
-priority integer DEFAULT add_priority(),
+rank bigint NOT NULL,
 removed bool DEFAULT false,
-created_at timestamp DEFAULT now(),
+removed_at timestamp,
+-- NOT NULL: по created_at строится курсор для sort=createdAt
+created_at timestamp NOT NULL DEFAULT now(),
+version integer NOT NULL DEFAULT 1,
 CONSTRAINT goods_pk PRIMARY KEY(id,project_id)
 );
 
-INSERT INTO  test_issue.projects (id, name, created_at) values(1,'Первая запись',now());
\ No newline at end of file
+-- по нему же считается приоритет: позиция товара - число товаров проекта с (rank, id) не больше его
+CREATE INDEX IF NOT EXISTS goods_rank_idx ON test_issue.goods (project_id, rank, id);
+CREATE INDEX IF NOT EXISTS goods_removed_at_idx ON test_issue.goods (removed_at) WHERE removed;
+
+-- подписки на события товаров проекта, пустой events - все события
+CREATE TABLE IF NOT EXISTS test_issue.webhooks (
+id integer PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+project_id integer NOT NULL REFERENCES test_issue.projects(id) ON DELETE CASCADE,
+url text NOT NULL,
+secret text NOT NULL,
+events text[] NOT NULL DEFAULT '{}',
+active bool NOT NULL DEFAULT true,
+created_at timestamp DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS webhooks_project_idx ON test_issue.webhooks (project_id);
+
+-- очередь и журнал доставок, status: pending, delivered, failed или skipped
+CREATE TABLE IF NOT EXISTS test_issue.webhook_deliveries (
+id bigint PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+webhook_id integer NOT NULL REFERENCES test_issue.webhooks(id) ON DELETE CASCADE,
+event text NOT NULL,
+payload jsonb NOT NULL,
+status text NOT NULL DEFAULT 'pending',
+attempts integer NOT NULL DEFAULT 0,
+response_status integer,
+last_error text,
+next_attempt_at timestamp DEFAULT now(),
+created_at timestamp DEFAULT now(),
+delivered_at timestamp
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx ON test_issue.webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_idx ON test_issue.webhook_deliveries (webhook_id, id);
+
+-- история изменений товаров, пишется из событий NATS. Записи об удаленных из базы товарах остаются
+CREATE TABLE IF NOT EXISTS test_issue.goods_history (
+id bigint PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+good_id integer NOT NULL,
+project_id integer NOT NULL,
+event text NOT NULL,
+name text,
+description text,
+priority integer,
+removed bool NOT NULL DEFAULT false,
+version integer,
+event_time timestamp NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS goods_history_project_idx ON test_issue.goods_history (project_id, id);
+CREATE INDEX IF NOT EXISTS goods_history_good_idx ON test_issue.goods_history (project_id, good_id, id);
+
+-- id не указываем явно, иначе identity выдаст его повторно при создании следующего проекта
+INSERT INTO  test_issue.projects (name, created_at) values('Первая запись',now());
\ No newline at end of file
@@ -3,6 +3,7 @@ package main
 import (
 	"log"
 	"main/database"
+	"main/grpcapi"
 	"main/handler"
 	natsLog "main/nats"
 	"net/http"
@@ -32,10 +33,49 @@ func main() {
 	}
 
 	r := handler.NewRestHandler(db, rdb, nc)
-	http.HandleFunc("/good", r.GetHandler)
-	http.HandleFunc("/good/create", r.PostHandler)
-	http.HandleFunc("/good/remove", r.DeleteHandler)
-	http.HandleFunc("/good/update", r.UpdateHandler)
-	http.HandleFunc("/good/reprioritiize", r.ReprioritiizeHandler)
-	http.ListenAndServe(":8080", nil)
+	r.Batch = cfg.Batch
+	exitOnCommand(r)
+	go r.RunPurger(cfg.Retention)
+
+	// события из NATS ставятся в очередь доставки подпискам, очередь разбирают все экземпляры
+	_, err = r.SubscribeWebhooks()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	go r.RunWebhooks(cfg.Webhooks)
+
+	// история товаров для GraphQL, событие записывает один из экземпляров
+	_, err = r.SubscribeHistory()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+
+	// повторы изменяющих запросов с тем же Idempotency-Key получают сохраненный ответ
+	idem := handler.NewIdempotency(rdb, cfg.Idempotency)
+
+	// тот же сервис товаров по gRPC, без него сервис не работает
+	go func() {
+		err := grpcapi.Serve(cfg.Grpc, r)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	gql, err := handler.NewGraphQL(r, cfg.GraphQL)
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+
+	// лента изменений для клиентов, события приходят из NATS от всех экземпляров
+	feed := handler.NewFeed()
+	_, err = feed.Subscribe(nc)
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+
+	http.ListenAndServe(":8080", handler.NewRouter(handler.Routes(r, idem, gql, feed)))
 }
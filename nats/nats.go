@@ -1,6 +1,9 @@
 package natsLog
 
 import (
+	"bytes"
+	"encoding/json"
+	"log"
 	"time"
 
 	"github.com/nats-io/nats.go"
@@ -19,15 +22,89 @@ type LogMessage struct {
 	Description *string   `json:"description,omitempty"`
 	Priority    int       `json:"priority,omitempty"`
 	Removed     bool      `json:"removed,omitempty"`
+	Purged      bool      `json:"purged,omitempty"`
+	Version     int       `json:"version,omitempty"`
+	Event       string    `json:"event"`
 	EventTime   time.Time `json:"event_time"`
 }
 
+// события в LogMessage.Event
+const (
+	EventCreate       = "create"
+	EventUpdate       = "update"
+	EventRemove       = "remove"
+	EventRestore      = "restore"
+	EventReprioritize = "reprioritize"
+	EventPurge        = "purge"
+)
+
 // GetNats подключаемся к nats
 func GetNats(cfg NatsConfig) (*nats.Conn, error) {
 	return nats.Connect(cfg.ConnString)
 }
 
+// subject тема, в которую пишется лог
+const subject = "test_issue"
+
 // SendLog отправляем в лог
 func SendLog(nc *nats.Conn, payload []byte) error {
-	return nc.Publish("test_issue", payload)
+	return nc.Publish(subject, payload)
+}
+
+// SendLogBatch отправляем в лог пачку сообщений одним сообщением, по строке JSON на каждое
+func SendLogBatch(nc *nats.Conn, msgs []LogMessage) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	lines := make([][]byte, 0, len(msgs))
+	for _, msg := range msgs {
+		out, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, out)
+	}
+	return SendLog(nc, bytes.Join(lines, []byte("\n")))
+}
+
+// Subscribe подписываемся на лог. Подписка без группы, поэтому каждый экземпляр сервиса
+// получает все сообщения. Пачка из SendLogBatch приходит в handle одним вызовом
+func Subscribe(nc *nats.Conn, handle func([]LogMessage)) (*nats.Subscription, error) {
+	return nc.Subscribe(subject, func(m *nats.Msg) {
+		msgs, err := ParseLog(m.Data)
+		if err != nil {
+			log.Print(err)
+			return
+		}
+		handle(msgs)
+	})
+}
+
+// QueueSubscribe подписываемся на лог в группе queue: каждое сообщение получает только один экземпляр сервиса
+func QueueSubscribe(nc *nats.Conn, queue string, handle func([]LogMessage)) (*nats.Subscription, error) {
+	return nc.QueueSubscribe(subject, queue, func(m *nats.Msg) {
+		msgs, err := ParseLog(m.Data)
+		if err != nil {
+			log.Print(err)
+			return
+		}
+		handle(msgs)
+	})
+}
+
+// ParseLog разбираем сообщение лога: один LogMessage или по строке JSON на каждый
+func ParseLog(data []byte) ([]LogMessage, error) {
+	msgs := []LogMessage{}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		msg := LogMessage{}
+		err := json.Unmarshal(line, &msg)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
 }